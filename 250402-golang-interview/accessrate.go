@@ -0,0 +1,122 @@
+package cache
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// accessWindow is how far back AccessRate looks, in one-second buckets.
+const accessWindow = 60
+
+// accessCounts is a ring of per-second counters for one key. Each bucket
+// remembers which second it counts, so a bucket left over from an earlier
+// lap of the ring reads as zero instead of needing to be cleared.
+type accessCounts struct {
+	counts [accessWindow]uint32
+	secs   [accessWindow]int64
+}
+
+func (a *accessCounts) add(sec int64, n uint32) {
+	i := sec % accessWindow
+	if a.secs[i] != sec {
+		a.secs[i], a.counts[i] = sec, 0
+	}
+	a.counts[i] += n
+}
+
+// total sums the buckets within the window ending at sec.
+func (a *accessCounts) total(sec int64) uint64 {
+	var sum uint64
+	for i, s := range a.secs {
+		if sec-s < accessWindow {
+			sum += uint64(a.counts[i])
+		}
+	}
+	return sum
+}
+
+// accessTracker counts sampled Gets for up to maxKeys keys. Once full, a new
+// key takes the slot of a key with no accesses left in the window, or isn't
+// tracked.
+type accessTracker struct {
+	maxKeys    int
+	sampleRate float64
+	sampler    *sampler // feeds record, independent of EnableSampling's
+	now        func() time.Time
+
+	mu   sync.Mutex
+	keys map[string]*accessCounts
+}
+
+func (t *accessTracker) record(id string) {
+	sec := t.now().Unix()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	a, ok := t.keys[id]
+	if !ok {
+		if len(t.keys) >= t.maxKeys && !t.evictIdle(sec) {
+			return
+		}
+		a = &accessCounts{}
+		t.keys[id] = a
+	}
+	a.add(sec, 1)
+}
+
+// evictIdle drops one key with nothing left in the window, reporting whether
+// it found one. The caller must hold t.mu.
+func (t *accessTracker) evictIdle(sec int64) bool {
+	for id, a := range t.keys {
+		if a.total(sec) == 0 {
+			delete(t.keys, id)
+			return true
+		}
+	}
+	return false
+}
+
+func (t *accessTracker) rate(id string) float64 {
+	sec := t.now().Unix()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	a, ok := t.keys[id]
+	if !ok {
+		return 0
+	}
+	return float64(a.total(sec)) / accessWindow / t.sampleRate
+}
+
+// TrackAccessRates starts counting Gets per key for AccessRate, for at most
+// maxKeys keys at a time. Only sampleRate (0 to 1] of Gets are counted, and
+// the counts are scaled back up. It samples on its own, so it leaves any
+// EnableSampling sink in place. A sampleRate outside (0, 1] or a maxKeys <= 0
+// is an error.
+func (c *ShardedCache) TrackAccessRates(maxKeys int, sampleRate float64) error {
+	if !(sampleRate > 0 && sampleRate <= 1) {
+		return fmt.Errorf("cache: access rate sample rate %v not in (0, 1]", sampleRate)
+	}
+	if maxKeys <= 0 {
+		return fmt.Errorf("cache: access rate max keys %d not positive", maxKeys)
+	}
+	t := &accessTracker{
+		maxKeys:    maxKeys,
+		sampleRate: sampleRate,
+		now:        time.Now,
+		keys:       make(map[string]*accessCounts),
+	}
+	t.sampler = newSampler(sampleRate, t.record)
+	c.rates.Store(t)
+	return nil
+}
+
+// AccessRate estimates id's Gets per second over the last minute. It is 0 for
+// keys that aren't tracked, including every key if TrackAccessRates was never
+// called.
+func (c *ShardedCache) AccessRate(id string) float64 {
+	t := c.rates.Load()
+	if t == nil {
+		return 0
+	}
+	return t.rate(id)
+}
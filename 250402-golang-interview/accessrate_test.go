@@ -0,0 +1,107 @@
+package cache
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestShardedCacheAccessRate(t *testing.T) {
+	clock := newFakeClock()
+	newCache := func(t *testing.T, maxKeys int, sampleRate float64) *ShardedCache {
+		c := NewShardedCache()
+		initCache(c)
+		if err := c.TrackAccessRates(maxKeys, sampleRate); err != nil {
+			t.Fatal(err)
+		}
+		c.rates.Load().now = clock.Now
+		return c
+	}
+
+	t.Run("Burst", func(t *testing.T) {
+		c := newCache(t, 10, 1)
+		for i := 0; i < 600; i++ {
+			c.Get("disk-1")
+		}
+		if got := c.AccessRate("disk-1"); got != 10 {
+			t.Errorf("expected 600 Gets over a minute to be 10/s, got %v", got)
+		}
+		if got := c.AccessRate("disk-2"); got != 0 {
+			t.Errorf("expected 0 for an unread key, got %v", got)
+		}
+
+		// The burst ages out of the window
+		clock.Advance(30 * time.Second)
+		if got := c.AccessRate("disk-1"); got != 10 {
+			t.Errorf("expected the burst still in the window, got %v", got)
+		}
+		clock.Advance(30 * time.Second)
+		if got := c.AccessRate("disk-1"); got != 0 {
+			t.Errorf("expected the burst aged out, got %v", got)
+		}
+	})
+
+	t.Run("Sampled", func(t *testing.T) {
+		c := newCache(t, 10, 0.1)
+		for i := 0; i < 60000; i++ {
+			c.Get("disk-1")
+		}
+		// 1000/s, from a Binomial(60000, 0.1) sample scaled back up
+		slack := 5 * math.Sqrt(60000*0.1*0.9) / 0.1 / 60
+		if got := c.AccessRate("disk-1"); math.Abs(got-1000) > slack {
+			t.Errorf("expected about 1000/s (±%.0f), got %v", slack, got)
+		}
+	})
+
+	t.Run("KeepsSamplingSink", func(t *testing.T) {
+		c := NewShardedCache()
+		var sampled int
+		c.EnableSampling(1, func(string) { sampled++ })
+		if err := c.TrackAccessRates(10, 1); err != nil {
+			t.Fatal(err)
+		}
+		c.rates.Load().now = clock.Now
+		for i := 0; i < 60; i++ {
+			c.Get("disk-1")
+		}
+		if sampled != 60 {
+			t.Errorf("expected the sampling sink to see 60 Gets, got %d", sampled)
+		}
+		if got := c.AccessRate("disk-1"); got != 1 {
+			t.Errorf("expected 1/s, got %v", got)
+		}
+	})
+
+	t.Run("BadArgs", func(t *testing.T) {
+		c := NewShardedCache()
+		for _, rate := range []float64{0, -1, 1.5, math.NaN()} {
+			if err := c.TrackAccessRates(10, rate); err == nil {
+				t.Errorf("expected an error for sample rate %v", rate)
+			}
+		}
+		if err := c.TrackAccessRates(0, 1); err == nil {
+			t.Errorf("expected an error for maxKeys 0")
+		}
+		if c.rates.Load() != nil {
+			t.Errorf("expected rejected calls not to start tracking")
+		}
+	})
+
+	t.Run("BoundedKeys", func(t *testing.T) {
+		c := newCache(t, 2, 1)
+		for i := 0; i < 3; i++ {
+			c.Get(fmt.Sprintf("disk-%d", i))
+		}
+		if got := c.AccessRate("disk-2"); got != 0 {
+			t.Errorf("expected a third key to go untracked, got %v", got)
+		}
+
+		// Once the first two go idle, a new key can take their slot
+		clock.Advance(time.Minute)
+		c.Get("disk-2")
+		if got := c.AccessRate("disk-2"); got == 0 {
+			t.Errorf("expected disk-2 tracked after the others went idle")
+		}
+	})
+}
@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"hash/maphash"
+	"math"
+	"sync/atomic"
+)
+
+// bloomFalsePositiveRate is what NewBloomShardedCache sizes its filter for.
+const bloomFalsePositiveRate = 0.01
+
+// NewBloomShardedCache puts a bloom filter sized for expectedKeys in front of
+// Get, so a miss on a key that was never stored returns without touching a
+// shard lock. A false positive just falls through to the normal lookup. Bits
+// are never cleared, not even by Delete or Clear, so under heavy key churn
+// the filter fills up and Get gradually degrades to the plain path.
+func NewBloomShardedCache(n, expectedKeys int) *ShardedCache {
+	c := NewShardedCacheWithShards(n)
+	c.bloom = newBloomFilter(expectedKeys, bloomFalsePositiveRate)
+	return c
+}
+
+// bloomAdd records id in the filter, if there is one. Writers call it before
+// storing, so the key is in the filter by the time any Get can find it.
+func (c *ShardedCache) bloomAdd(id string) {
+	if c.bloom != nil {
+		c.bloom.add(id)
+	}
+}
+
+// bloomFilter is a lock-free bloom filter: bits are set with atomic Or and
+// read with atomic Load, so adds and lookups can race freely.
+type bloomFilter struct {
+	bits []atomic.Uint64
+	m    uint64 // number of bits
+	k    int    // probes per key
+	seed maphash.Seed
+}
+
+func newBloomFilter(expectedKeys int, fpRate float64) *bloomFilter {
+	n := float64(max(expectedKeys, 1))
+	m := uint64(math.Ceil(-n * math.Log(fpRate) / (math.Ln2 * math.Ln2)))
+	m = max((m+63)/64*64, 64)
+	k := max(int(math.Round(float64(m)/n*math.Ln2)), 1)
+	return &bloomFilter{
+		bits: make([]atomic.Uint64, m/64),
+		m:    m,
+		k:    k,
+		seed: maphash.MakeSeed(),
+	}
+}
+
+// hashes splits one 64-bit hash into the two halves that double hashing
+// combines into all k probe positions.
+func (f *bloomFilter) hashes(id string) (h1, h2 uint64) {
+	h := maphash.String(f.seed, id)
+	return h & math.MaxUint32, h>>32 | 1
+}
+
+func (f *bloomFilter) add(id string) {
+	h1, h2 := f.hashes(id)
+	for i := 0; i < f.k; i++ {
+		bit := (h1 + uint64(i)*h2) % f.m
+		f.bits[bit/64].Or(1 << (bit % 64))
+	}
+}
+
+// mayContain is false only if id was definitely never added.
+func (f *bloomFilter) mayContain(id string) bool {
+	h1, h2 := f.hashes(id)
+	for i := 0; i < f.k; i++ {
+		bit := (h1 + uint64(i)*h2) % f.m
+		if f.bits[bit/64].Load()&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,76 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestBloomShardedCache(t *testing.T) {
+	c := NewBloomShardedCache(ShardCount, numKeys)
+
+	t.Run("NoFalseNegatives", func(t *testing.T) {
+		initCache(c)
+		c.UpdateBatch(map[string]*DiskStatus{"batch-1": {ID: "batch-1"}})
+		c.UpdateIfAbsent("absent-1", &DiskStatus{ID: "absent-1"})
+		c.IncrementTemp("incr-1", 1)
+		c.WithShard("shard-1", func(m map[string]*DiskStatus) {
+			m["shard-1"] = &DiskStatus{ID: "shard-1"}
+		})
+		for _, id := range append(c.Keys(), "batch-1", "absent-1", "incr-1", "shard-1") {
+			if got := c.Get(id); got == nil {
+				t.Fatalf("expected %s to be found", id)
+			}
+		}
+	})
+
+	t.Run("FiltersMisses", func(t *testing.T) {
+		const probes = 10000
+		passed := 0
+		for i := 0; i < probes; i++ {
+			id := fmt.Sprintf("missing-%d", i)
+			if c.bloom.mayContain(id) {
+				passed++
+			}
+			if got := c.Get(id); got != nil {
+				t.Fatalf("expected nil for %s, got %v", id, got)
+			}
+		}
+		// Sized for 1%; allow for the few extra keys and sampling noise
+		if rate := float64(passed) / probes; rate > 0.03 {
+			t.Errorf("expected about 1%% false positives, got %.2f%%", rate*100)
+		}
+	})
+}
+
+// Benchmark: Gets where 9 in 10 ids were never stored. The filter costs a
+// hash per Get, so it only wins once skipped shard locks are contended.
+func BenchmarkBloomMisses(b *testing.B) {
+	caches := []struct {
+		name string
+		new  func() Cache
+	}{
+		{"Sharded", func() Cache { return NewShardedCache() }},
+		{"BloomSharded", func() Cache { return NewBloomShardedCache(ShardCount, numKeys) }},
+	}
+	ids := make([]string, 10*numKeys)
+	for i := range ids {
+		if i%10 == 0 {
+			ids[i] = fmt.Sprintf("disk-%d", i/10)
+		} else {
+			ids[i] = fmt.Sprintf("missing-%d", i)
+		}
+	}
+	for _, impl := range caches {
+		b.Run(impl.name, func(b *testing.B) {
+			c := initCache(impl.new())
+			b.SetParallelism(benchParallel)
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					c.Get(ids[i%len(ids)])
+					i++
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package cache
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// 12. Bounded Copy-on-Write Cache
+//
+// COWCache with a size cap, so the per-write copy stops growing with the
+// number of keys ever written. When an Update adds a key to a full map, the
+// victim is dropped during the same copy. The victim is the smallest id,
+// which is arbitrary but deterministic and needs no bookkeeping beyond the
+// copy loop itself.
+type BoundedCOWCache struct {
+	maxEntries int
+	mu         sync.Mutex   // serializes writers; readers never take it
+	disks      atomic.Value // stores map[string]*DiskStatus
+}
+
+var _ Cache = (*BoundedCOWCache)(nil)
+
+// NewBoundedCOWCache returns a cache holding at most maxEntries entries.
+// maxEntries <= 0 means no limit, which makes it a plain COWCache.
+func NewBoundedCOWCache(maxEntries int) *BoundedCOWCache {
+	c := &BoundedCOWCache{maxEntries: maxEntries}
+	c.disks.Store(make(map[string]*DiskStatus))
+	return c
+}
+
+func (c *BoundedCOWCache) Get(id string) *DiskStatus {
+	return c.disks.Load().(map[string]*DiskStatus)[id]
+}
+
+func (c *BoundedCOWCache) Contains(id string) bool {
+	_, ok := c.disks.Load().(map[string]*DiskStatus)[id]
+	return ok
+}
+
+func (c *BoundedCOWCache) Update(id string, status *DiskStatus) {
+	if status == nil {
+		c.Delete(id)
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	old := c.disks.Load().(map[string]*DiskStatus)
+	_, exists := old[id]
+	full := !exists && c.maxEntries > 0 && len(old) >= c.maxEntries
+
+	victim := ""
+	if full {
+		first := true
+		for k := range old {
+			if first || k < victim {
+				victim, first = k, false
+			}
+		}
+	}
+	new := make(map[string]*DiskStatus, len(old)+1)
+	for k, v := range old {
+		if !full || k != victim {
+			new[k] = v
+		}
+	}
+	new[id] = status
+	c.disks.Store(new)
+}
+
+func (c *BoundedCOWCache) Delete(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	old := c.disks.Load().(map[string]*DiskStatus)
+	if _, ok := old[id]; !ok {
+		return
+	}
+	new := make(map[string]*DiskStatus, len(old))
+	for k, v := range old {
+		if k != id {
+			new[k] = v
+		}
+	}
+	c.disks.Store(new)
+}
+
+func (c *BoundedCOWCache) Len() int {
+	return len(c.disks.Load().(map[string]*DiskStatus))
+}
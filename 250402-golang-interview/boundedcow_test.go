@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestBoundedCOWCache(t *testing.T) {
+	const maxEntries = 10
+
+	t.Run("LenNeverExceedsBound", func(t *testing.T) {
+		c := NewBoundedCOWCache(maxEntries)
+		for i := 0; i < 5*maxEntries; i++ {
+			id := fmt.Sprintf("disk-%03d", i)
+			c.Update(id, &DiskStatus{ID: id})
+			if got := c.Len(); got > maxEntries {
+				t.Fatalf("after %d updates: expected Len <= %d, got %d", i+1, maxEntries, got)
+			}
+			if got := c.Get(id); got == nil {
+				t.Fatalf("expected just written %s to be present", id)
+			}
+		}
+	})
+
+	t.Run("SmallestIDEvicted", func(t *testing.T) {
+		c := NewBoundedCOWCache(3)
+		for _, id := range []string{"disk-b", "disk-a", "disk-c"} {
+			c.Update(id, &DiskStatus{ID: id})
+		}
+		c.Update("disk-d", &DiskStatus{ID: "disk-d"})
+		if c.Contains("disk-a") {
+			t.Errorf("expected disk-a to be evicted")
+		}
+		for _, id := range []string{"disk-b", "disk-c", "disk-d"} {
+			if !c.Contains(id) {
+				t.Errorf("expected %s to survive", id)
+			}
+		}
+	})
+
+	t.Run("OverwriteDoesNotEvict", func(t *testing.T) {
+		c := NewBoundedCOWCache(2)
+		c.Update("disk-a", &DiskStatus{ID: "disk-a"})
+		c.Update("disk-b", &DiskStatus{ID: "disk-b"})
+		c.Update("disk-b", &DiskStatus{ID: "disk-b", Temp: 50})
+		if got := c.Len(); got != 2 || !c.Contains("disk-a") {
+			t.Errorf("expected both entries kept, got Len %d", got)
+		}
+	})
+}
+
+// Benchmark: writes cycling over a growing key space. COWCache copies every
+// key ever written, while BoundedCOWCache's copy plateaus at its bound.
+func BenchmarkBoundedCOWWrite(b *testing.B) {
+	const maxEntries = 500
+	caches := []struct {
+		name string
+		new  func() Cache
+	}{
+		{"COW", func() Cache { return NewCOWCache() }},
+		{"BoundedCOW", func() Cache { return NewBoundedCOWCache(maxEntries) }},
+	}
+	for _, impl := range caches {
+		for _, keys := range []int{500, 1000, 2000, 4000} {
+			b.Run(fmt.Sprintf("%s/keys=%d", impl.name, keys), func(b *testing.B) {
+				c := impl.new()
+				for i := 0; i < keys; i++ {
+					id := fmt.Sprintf("disk-%d", i)
+					c.Update(id, &DiskStatus{ID: id})
+				}
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					id := fmt.Sprintf("disk-%d", i%keys)
+					c.Update(id, &DiskStatus{ID: id, Health: 100, Temp: 45})
+				}
+			})
+		}
+	}
+}
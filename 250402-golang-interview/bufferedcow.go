@@ -0,0 +1,200 @@
+package cache
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 13. Buffered Copy-on-Write Cache
+//
+// COWCache for bursty writers: Updates land in a small pending map, and once
+// it holds mergeAfter keys they are all applied with a single copy of the
+// base map. A burst of n writes costs n/mergeAfter copies instead of n. Reads
+// check the pending map first, so they see every write immediately, but that
+// check takes a mutex whenever anything is pending. So that a burst smaller
+// than mergeAfter doesn't keep every read on that path, the first read to find
+// writes pending for longer than maxDelay merges them.
+type BufferedCOWCache struct {
+	counters
+	mergeAfter int
+	maxDelay   time.Duration
+	now        func() time.Time
+	base       atomic.Value // stores map[string]*DiskStatus, never mutated
+
+	mu           sync.Mutex             // guards pending and serializes merges
+	pending      map[string]*DiskStatus // nil value = deleted since the last merge
+	pendingSince time.Time              // when the oldest pending write was buffered
+	pendingLen   atomic.Int32           // len(pending), so reads skip mu when it's empty
+
+	copies        atomic.Uint64
+	copiedEntries atomic.Uint64
+}
+
+var _ Cache = (*BufferedCOWCache)(nil)
+
+// DefaultMergeAfter is how many pending keys NewBufferedCOWCache buffers
+// before merging.
+const DefaultMergeAfter = 64
+
+// DefaultMergeDelay is how long NewBufferedCOWCache lets writes stay pending
+// before a read merges them.
+const DefaultMergeDelay = 10 * time.Millisecond
+
+// NewBufferedCOWCache merges pending writes into the base map once mergeAfter
+// distinct keys are buffered, or DefaultMergeDelay after the oldest of them.
+// mergeAfter <= 0 falls back to DefaultMergeAfter.
+func NewBufferedCOWCache(mergeAfter int) *BufferedCOWCache {
+	return NewBufferedCOWCacheWithDelay(mergeAfter, DefaultMergeDelay)
+}
+
+// NewBufferedCOWCacheWithDelay is NewBufferedCOWCache with the delay after
+// which a read merges pending writes. maxDelay <= 0 makes every read that
+// finds pending writes merge them.
+func NewBufferedCOWCacheWithDelay(mergeAfter int, maxDelay time.Duration) *BufferedCOWCache {
+	if mergeAfter <= 0 {
+		mergeAfter = DefaultMergeAfter
+	}
+	c := &BufferedCOWCache{
+		mergeAfter: mergeAfter,
+		maxDelay:   maxDelay,
+		now:        time.Now,
+		pending:    make(map[string]*DiskStatus),
+	}
+	c.base.Store(make(map[string]*DiskStatus))
+	return c
+}
+
+// lookup checks the pending writes, then the base map. Pending writes older
+// than maxDelay are merged first, returning later reads to the lock-free path.
+func (c *BufferedCOWCache) lookup(id string) (*DiskStatus, bool) {
+	if c.pendingLen.Load() > 0 {
+		c.mu.Lock()
+		if len(c.pending) > 0 && c.now().Sub(c.pendingSince) >= c.maxDelay {
+			c.mergeLocked()
+		}
+		status, ok := c.pending[id]
+		c.mu.Unlock()
+		if ok {
+			return status, status != nil
+		}
+	}
+	status, ok := c.base.Load().(map[string]*DiskStatus)[id]
+	return status, ok
+}
+
+func (c *BufferedCOWCache) Get(id string) *DiskStatus {
+	status, _ := c.lookup(id)
+	c.recordGet(status)
+	return status
+}
+
+func (c *BufferedCOWCache) Contains(id string) bool {
+	_, ok := c.lookup(id)
+	return ok
+}
+
+func (c *BufferedCOWCache) Update(id string, status *DiskStatus) {
+	if status == nil {
+		c.Delete(id)
+		return
+	}
+	c.recordUpdate()
+	c.buffer(id, status)
+}
+
+func (c *BufferedCOWCache) Delete(id string) {
+	c.buffer(id, nil)
+}
+
+func (c *BufferedCOWCache) buffer(id string, status *DiskStatus) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.pending) == 0 {
+		c.pendingSince = c.now()
+	}
+	c.pending[id] = status
+	c.pendingLen.Store(int32(len(c.pending)))
+	if len(c.pending) >= c.mergeAfter {
+		c.mergeLocked()
+	}
+}
+
+// Flush merges any pending writes now, e.g. before handing out GetAll-style
+// views or when a burst is known to be over.
+func (c *BufferedCOWCache) Flush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.pending) > 0 {
+		c.mergeLocked()
+	}
+}
+
+// mergeLocked publishes a new base map with the pending writes applied, then
+// empties the buffer. The base is stored first, so a reader never finds a
+// write in neither place. The caller must hold c.mu.
+func (c *BufferedCOWCache) mergeLocked() {
+	old := c.base.Load().(map[string]*DiskStatus)
+	new := make(map[string]*DiskStatus, len(old)+len(c.pending))
+	for k, v := range old {
+		new[k] = v
+	}
+	for k, v := range c.pending {
+		if v == nil {
+			delete(new, k)
+		} else {
+			new[k] = v
+		}
+	}
+	c.base.Store(new)
+	c.copies.Add(1)
+	c.copiedEntries.Add(uint64(len(old)))
+
+	c.pending = make(map[string]*DiskStatus, c.mergeAfter)
+	c.pendingLen.Store(0)
+}
+
+// Stats reports copy cost like COWCache.Stats.
+func (c *BufferedCOWCache) Stats() Stats {
+	s := c.counters.Stats()
+	s.Copies = c.copies.Load()
+	s.CopiedEntries = c.copiedEntries.Load()
+	return s
+}
+
+// Len counts the base map adjusted for pending writes, without merging.
+func (c *BufferedCOWCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	base := c.base.Load().(map[string]*DiskStatus)
+	n := len(base)
+	for id, status := range c.pending {
+		_, inBase := base[id]
+		switch {
+		case status == nil && inBase:
+			n--
+		case status != nil && !inBase:
+			n++
+		}
+	}
+	return n
+}
+
+// Snapshot returns the merged view of base and pending writes, cloned.
+func (c *BufferedCOWCache) Snapshot() map[string]*DiskStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	base := c.base.Load().(map[string]*DiskStatus)
+	snap := make(map[string]*DiskStatus, len(base)+len(c.pending))
+	for id, status := range base {
+		snap[id] = cloneStatus(status)
+	}
+	for id, status := range c.pending {
+		if status == nil {
+			delete(snap, id)
+		} else {
+			snap[id] = cloneStatus(status)
+		}
+	}
+	return snap
+}
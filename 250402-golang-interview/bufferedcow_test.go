@@ -0,0 +1,112 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestBufferedCOWCache(t *testing.T) {
+	t.Run("ReadsSeePendingWrites", func(t *testing.T) {
+		c := NewBufferedCOWCache(4)
+		c.now = newFakeClock().Now
+		c.Update("disk-1", &DiskStatus{ID: "disk-1", Temp: 30})
+		if got := c.Get("disk-1"); got == nil || got.Temp != 30 {
+			t.Fatalf("expected pending disk-1, got %v", got)
+		}
+		if got := c.Stats().Copies; got != 0 {
+			t.Errorf("expected no copies before the merge threshold, got %d", got)
+		}
+
+		c.Update("disk-1", &DiskStatus{ID: "disk-1", Temp: 31})
+		c.Delete("disk-2")
+		c.Update("disk-3", &DiskStatus{ID: "disk-3"})
+		if got := c.Get("disk-1"); got == nil || got.Temp != 31 {
+			t.Errorf("expected latest pending write, got %v", got)
+		}
+		if got := c.Len(); got != 2 {
+			t.Errorf("expected Len 2, got %d", got)
+		}
+	})
+
+	t.Run("MergeAtThreshold", func(t *testing.T) {
+		c := NewBufferedCOWCache(4)
+		c.now = newFakeClock().Now
+		for i := 0; i < 4; i++ {
+			id := fmt.Sprintf("disk-%d", i)
+			c.Update(id, &DiskStatus{ID: id})
+		}
+		if got := c.Stats().Copies; got != 1 {
+			t.Fatalf("expected one merge, got %d copies", got)
+		}
+		if got := len(c.base.Load().(map[string]*DiskStatus)); got != 4 {
+			t.Errorf("expected 4 merged entries, got %d", got)
+		}
+
+		// A pending delete shadows the base entry until it's merged
+		c.Delete("disk-0")
+		if c.Contains("disk-0") || c.Len() != 3 {
+			t.Errorf("expected disk-0 deleted before the merge, Len %d", c.Len())
+		}
+		c.Flush()
+		if c.Contains("disk-0") || len(c.Snapshot()) != 3 {
+			t.Errorf("expected disk-0 deleted after Flush")
+		}
+	})
+
+	t.Run("ReadMergesAfterDelay", func(t *testing.T) {
+		clock := newFakeClock()
+		c := NewBufferedCOWCacheWithDelay(DefaultMergeAfter, time.Second)
+		c.now = clock.Now
+		status := &DiskStatus{ID: "disk-1"}
+		c.Update("disk-1", status)
+		c.Get("disk-1")
+		if c.pendingLen.Load() != 1 {
+			t.Fatalf("expected the write still pending before the delay")
+		}
+
+		clock.Advance(time.Second)
+		if got := c.Get("disk-1"); got != status {
+			t.Fatalf("expected %v, got %v", status, got)
+		}
+		// The write now sits in the base map, where reads skip the mutex
+		if c.pendingLen.Load() != 0 || c.base.Load().(map[string]*DiskStatus)["disk-1"] != status {
+			t.Errorf("expected the read to merge the lone pending write")
+		}
+		if got := c.Stats().Copies; got != 1 {
+			t.Errorf("expected one merge, got %d copies", got)
+		}
+	})
+}
+
+// Benchmark: bursts of writes over numKeys entries. COWCache copies the map
+// once per write, BufferedCOWCache once per DefaultMergeAfter keys.
+func BenchmarkBufferedCOWBurst(b *testing.B) {
+	const burst = 256
+	type statsCache interface {
+		Cache
+		Stats() Stats
+	}
+	caches := []struct {
+		name string
+		new  func() statsCache
+	}{
+		{"COW", func() statsCache { return NewCOWCache() }},
+		{"BufferedCOW", func() statsCache { return NewBufferedCOWCache(DefaultMergeAfter) }},
+	}
+	for _, impl := range caches {
+		b.Run(impl.name, func(b *testing.B) {
+			c := impl.new()
+			initCache(c)
+			before := c.Stats().Copies
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for j := 0; j < burst; j++ {
+					id := fmt.Sprintf("disk-%d", (i*burst+j)%numKeys)
+					c.Update(id, &DiskStatus{ID: id, Health: 100, Temp: 45})
+				}
+			}
+			b.ReportMetric(float64(c.Stats().Copies-before)/float64(b.N), "copies/op")
+		})
+	}
+}
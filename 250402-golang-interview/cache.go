@@ -1,10 +1,14 @@
 package cache
 
 import (
-	"hash/fnv"
+	"context"
+	"hash/maphash"
+	"maps"
+	"math"
 	"runtime"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 type DiskStatus struct {
@@ -13,230 +17,2110 @@ type DiskStatus struct {
 	Temp   int
 }
 
+// cloneStatus returns a copy of status, or nil. DiskStatus only has value
+// fields, so a struct copy is a deep copy.
+func cloneStatus(status *DiskStatus) *DiskStatus {
+	if status == nil {
+		return nil
+	}
+	clone := *status
+	return &clone
+}
+
+// Cache is the common API shared by every implementation, so callers can
+// swap strategies at runtime and benchmarks can drive them uniformly.
+type Cache interface {
+	Get(id string) *DiskStatus
+	// Update stores status for id. A nil status deletes id instead, so a nil
+	// from Get always means the key is absent.
+	Update(id string, status *DiskStatus)
+}
+
+func tempField(s *DiskStatus) *int   { return &s.Temp }
+func healthField(s *DiskStatus) *int { return &s.Health }
+
+// incremented returns a copy of current (or a fresh status for id) with delta
+// added to field.
+func incremented(id string, current *DiskStatus, field func(*DiskStatus) *int, delta int) *DiskStatus {
+	next := cloneStatus(current)
+	if next == nil {
+		next = &DiskStatus{ID: id}
+	}
+	*field(next) += delta
+	return next
+}
+
+// Stats is a point-in-time view of a cache's activity counters.
+type Stats struct {
+	Hits    uint64 // Gets that found a value
+	Misses  uint64 // Gets that returned nil
+	Updates uint64
+
+	// Copy-on-write caches only: how many times a write copied a map, and
+	// how many entries those copies moved in total
+	Copies        uint64
+	CopiedEntries uint64
+}
+
+// counters is embedded in each cache. The increments are atomic so collecting
+// stats never takes the cache's own lock, though the shared counters still
+// bounce a cache line between cores on every Get.
+type counters struct {
+	hits    atomic.Uint64
+	misses  atomic.Uint64
+	updates atomic.Uint64
+}
+
+func (s *counters) recordGet(status *DiskStatus) {
+	if status != nil {
+		s.hits.Add(1)
+	} else {
+		s.misses.Add(1)
+	}
+}
+
+func (s *counters) recordUpdate() {
+	s.updates.Add(1)
+}
+
+func (s *counters) Stats() Stats {
+	return Stats{
+		Hits:    s.hits.Load(),
+		Misses:  s.misses.Load(),
+		Updates: s.updates.Load(),
+	}
+}
+
+var (
+	_ Cache = (*MutexCache)(nil)
+	_ Cache = (*RWMutexCache)(nil)
+	_ Cache = (*ShardedCache)(nil)
+	_ Cache = (*SyncMapCache)(nil)
+	_ Cache = (*SpinLockCache)(nil)
+	_ Cache = (*COWCache)(nil)
+	_ Cache = (*HybridCache)(nil)
+)
+
 // 1. Basic Mutex Cache
+//
+// A wrapper around GenericMutexCache holding entries rather than bare values,
+// so TTLs and versions live next to each status under the same lock.
 type MutexCache struct {
-	mu    sync.Mutex
-	disks map[string]*DiskStatus
+	counters
+	keyNormalizer
+	base    GenericMutexCache[entry]
+	now     func() time.Time // swappable clock for TTL tests
+	onEvict evictions
+	waits   *lockWaits // nil unless built by NewInstrumentedMutexCache
+	gen     uint64     // bumped by every write, see ChangedSince
+	frozen  atomic.Bool
+
+	janitorMu sync.Mutex
+	janitors  []func() // stop funcs of running janitors, for Close
+}
+
+// entry pairs a cached value with its expiry; a zero expiresAt never expires.
+// version counts the writes to the key since it was last absent, and gen is
+// the cache generation of the latest one. createdAt is the first of those
+// writes and updatedAt the latest.
+type entry struct {
+	status    *DiskStatus
+	expiresAt time.Time
+	version   uint64
+	gen       uint64
+	createdAt time.Time
+	updatedAt time.Time
+}
+
+func NewMutexCache() *MutexCache {
+	return &MutexCache{
+		base: GenericMutexCache[entry]{data: make(map[string]entry)},
+		now:  time.Now,
+	}
+}
+
+// NewInstrumentedMutexCache records how long every operation waits for the
+// lock, readable through LockWaitStats. Plain NewMutexCache skips the
+// measurement entirely.
+func NewInstrumentedMutexCache() *MutexCache {
+	c := NewMutexCache()
+	c.waits = &lockWaits{}
+	return c
+}
+
+// LockWaitStats returns the lock wait histogram, or zero stats for a cache
+// that isn't instrumented.
+func (c *MutexCache) LockWaitStats() LockWaitStats {
+	if c.waits == nil {
+		return LockWaitStats{}
+	}
+	return c.waits.stats()
+}
+
+// lock acquires c.base.mu, timing the wait when instrumented. An uncontended
+// TryLock is recorded as a zero wait without reading the clock.
+func (c *MutexCache) lock() {
+	if c.waits == nil {
+		c.base.mu.Lock()
+		return
+	}
+	if c.base.mu.TryLock() {
+		c.waits.record(0)
+		return
+	}
+	start := time.Now()
+	c.base.mu.Lock()
+	c.waits.record(time.Since(start))
+}
+
+// lookup returns the live value for id, lazily deleting it if it has expired.
+// Callers must hold c.base.mu.
+func (c *MutexCache) lookup(id string) (*DiskStatus, bool) {
+	e, ok := c.base.data[id]
+	if !ok {
+		return nil, false
+	}
+	if !e.expiresAt.IsZero() && !c.now().Before(e.expiresAt) {
+		delete(c.base.data, id)
+		c.onEvict.add(id, e.status)
+		return nil, false
+	}
+	return e.status, true
+}
+
+// put stores status for id, bumping its version and the cache generation.
+// Callers must hold c.base.mu.
+func (c *MutexCache) put(id string, status *DiskStatus, expiresAt time.Time) {
+	c.gen++
+	c.base.data[id] = c.next(c.base.data[id], status, expiresAt)
+}
+
+// remove deletes id, reporting it to OnEvict. Callers must hold c.base.mu.
+func (c *MutexCache) remove(id string) {
+	if e, ok := c.base.data[id]; ok {
+		delete(c.base.data, id)
+		c.onEvict.add(id, e.status)
+	}
+}
+
+// next returns the entry that replaces prev, the zero entry for a new key.
+func (c *MutexCache) next(prev entry, status *DiskStatus, expiresAt time.Time) entry {
+	now := c.now()
+	if prev.version == 0 {
+		prev.createdAt = now
+	}
+	return entry{
+		status:    status,
+		expiresAt: expiresAt,
+		version:   prev.version + 1,
+		gen:       c.gen,
+		createdAt: prev.createdAt,
+		updatedAt: now,
+	}
+}
+
+// unlock releases c.base.mu, then reports entries removed while it was held.
+func (c *MutexCache) unlock() {
+	fn, removed := c.onEvict.take()
+	c.base.mu.Unlock()
+	notify(fn, removed)
+}
+
+// OnEvict registers fn to be called after an entry leaves the cache through
+// TTL expiry, Delete, Clear, or UpdateFunc returning nil. GetAndDelete hands
+// the value to its caller instead. fn runs outside the lock, so it may call
+// back into the cache.
+func (c *MutexCache) OnEvict(fn func(id string, status *DiskStatus)) {
+	c.lock()
+	defer c.unlock()
+	c.onEvict.fn = fn
+}
+
+func (c *MutexCache) Get(id string) *DiskStatus {
+	id = c.normalize(id)
+	c.lock()
+	defer c.unlock()
+	status, _ := c.lookup(id)
+	c.recordGet(status)
+	return status
+}
+
+// NoExpiry is the remaining lifetime GetWithExpiry reports for entries stored
+// without a TTL.
+const NoExpiry = time.Duration(math.MaxInt64)
+
+// GetWithExpiry is Get that also reports how long the entry has left, or
+// NoExpiry if it was stored without a TTL. ok is false for a missing or
+// expired entry.
+func (c *MutexCache) GetWithExpiry(id string) (status *DiskStatus, ttl time.Duration, ok bool) {
+	id = c.normalize(id)
+	c.lock()
+	defer c.unlock()
+	status, ok = c.lookup(id)
+	c.recordGet(status)
+	if !ok {
+		return nil, 0, false
+	}
+	if expiresAt := c.base.data[id].expiresAt; !expiresAt.IsZero() {
+		return status, expiresAt.Sub(c.now()), true
+	}
+	return status, NoExpiry, true
+}
+
+// GetStale is Get that also returns an expired entry still held by the cache,
+// flagged stale, for callers that would rather serve old data than nothing
+// while they refresh it. It leaves the entry in place for the next write to
+// replace; a plain Get, sweep or Delete still removes it.
+func (c *MutexCache) GetStale(id string) (status *DiskStatus, stale bool, ok bool) {
+	id = c.normalize(id)
+	c.lock()
+	defer c.unlock()
+	e, ok := c.base.data[id]
+	c.recordGet(e.status)
+	if !ok {
+		return nil, false, false
+	}
+	stale = !e.expiresAt.IsZero() && !c.now().Before(e.expiresAt)
+	return e.status, stale, true
+}
+
+// GetVersioned is Get that also returns the entry's version, which increases
+// with every write to id. Pollers can compare versions instead of values; the
+// count restarts once the key is removed.
+func (c *MutexCache) GetVersioned(id string) (*DiskStatus, uint64, bool) {
+	id = c.normalize(id)
+	c.lock()
+	defer c.unlock()
+	status, ok := c.lookup(id)
+	c.recordGet(status)
+	if !ok {
+		return nil, 0, false
+	}
+	return status, c.base.data[id].version, true
+}
+
+// GetMeta is Get that also returns when id was first stored and last
+// written, for debugging staleness. createdAt restarts once the key is removed.
+func (c *MutexCache) GetMeta(id string) (status *DiskStatus, createdAt, updatedAt time.Time, ok bool) {
+	id = c.normalize(id)
+	c.lock()
+	defer c.unlock()
+	status, ok = c.lookup(id)
+	c.recordGet(status)
+	if !ok {
+		return nil, time.Time{}, time.Time{}, false
+	}
+	e := c.base.data[id]
+	return status, e.createdAt, e.updatedAt, true
+}
+
+// ChangedSince returns the live entries written after generation gen, plus the
+// current generation to pass to the next call. Start from 0 to get everything.
+// Deletions aren't reported; compare Keys for those.
+func (c *MutexCache) ChangedSince(gen uint64) ([]*DiskStatus, uint64) {
+	c.lock()
+	defer c.unlock()
+	now := c.now()
+	var changed []*DiskStatus
+	for _, e := range c.base.data {
+		if e.gen > gen && (e.expiresAt.IsZero() || now.Before(e.expiresAt)) {
+			changed = append(changed, e.status)
+		}
+	}
+	return changed, c.gen
+}
+
+// GetCopy is Get returning a private copy, so mutating it can't corrupt the
+// shared cached value.
+func (c *MutexCache) GetCopy(id string) *DiskStatus {
+	return cloneStatus(c.Get(id))
+}
+
+func (c *MutexCache) Update(id string, status *DiskStatus) {
+	c.UpdateOK(id, status)
+}
+
+// UpdateOK is Update reporting whether it stored, which it doesn't while the
+// cache is frozen.
+func (c *MutexCache) UpdateOK(id string, status *DiskStatus) bool {
+	id = c.normalize(id)
+	c.lock()
+	defer c.unlock()
+	if c.frozen.Load() {
+		return false
+	}
+	if status == nil {
+		c.remove(id)
+		return true
+	}
+	c.recordUpdate()
+	c.put(id, status, time.Time{})
+	return true
+}
+
+func (c *MutexCache) Contains(id string) bool {
+	id = c.normalize(id)
+	c.lock()
+	defer c.unlock()
+	_, ok := c.lookup(id)
+	return ok
+}
+
+// GetBatch looks up all ids under a single lock acquisition. Missing keys are
+// absent from the result rather than mapped to nil; found ones are keyed by
+// the ids as given.
+func (c *MutexCache) GetBatch(ids []string) map[string]*DiskStatus {
+	c.lock()
+	defer c.unlock()
+	result := make(map[string]*DiskStatus, len(ids))
+	for _, id := range ids {
+		status, ok := c.lookup(c.normalize(id))
+		if ok {
+			result[id] = status
+		}
+		c.recordGet(status)
+	}
+	return result
+}
+
+// CompareAndUpdate stores new only if the current value is the very pointer
+// old (nil meaning absent), and reports whether it did. A nil new deletes.
+// Callers retry by re-reading with Get.
+func (c *MutexCache) CompareAndUpdate(id string, old, new *DiskStatus) bool {
+	id = c.normalize(id)
+	c.lock()
+	defer c.unlock()
+	if c.frozen.Load() {
+		return false
+	}
+	if current, _ := c.lookup(id); current != old {
+		return false
+	}
+	if new == nil {
+		c.remove(id)
+		return true
+	}
+	c.recordUpdate()
+	c.put(id, new, time.Time{})
+	return true
+}
+
+// UpdateIfAbsent stores status only if id is missing (or expired), so it can
+// initialize an entry without overwriting fresher data. It reports whether it
+// stored.
+func (c *MutexCache) UpdateIfAbsent(id string, status *DiskStatus) bool {
+	id = c.normalize(id)
+	c.lock()
+	defer c.unlock()
+	if c.frozen.Load() {
+		return false
+	}
+	if _, ok := c.lookup(id); ok {
+		return false
+	}
+	if status == nil {
+		// Clears an expired entry that is still in the map
+		c.remove(id)
+		return true
+	}
+	c.recordUpdate()
+	c.put(id, status, time.Time{})
+	return true
+}
+
+// Swap stores status and returns the value it replaced, or nil if id was
+// missing (or expired), as one locked step. A nil status deletes.
+func (c *MutexCache) Swap(id string, status *DiskStatus) *DiskStatus {
+	id = c.normalize(id)
+	c.lock()
+	defer c.unlock()
+	if c.frozen.Load() {
+		return nil
+	}
+	old, _ := c.lookup(id)
+	if status == nil {
+		c.remove(id)
+		return old
+	}
+	c.recordUpdate()
+	c.put(id, status, time.Time{})
+	return old
+}
+
+// UpdateFunc replaces the value for id with fn(current) under the lock, making
+// read-modify-write atomic. current is nil when absent; returning nil deletes
+// the entry. fn should return a new value rather than mutate current, which
+// readers may still hold.
+func (c *MutexCache) UpdateFunc(id string, fn func(*DiskStatus) *DiskStatus) {
+	id = c.normalize(id)
+	c.lock()
+	defer c.unlock()
+	if c.frozen.Load() {
+		return
+	}
+	current, ok := c.lookup(id)
+	next := fn(current)
+	if next == nil {
+		if ok {
+			c.remove(id)
+		}
+		return
+	}
+	c.recordUpdate()
+	c.put(id, next, time.Time{})
+}
+
+// IncrementTemp adds delta to id's Temp under the lock and returns the new
+// value. The stored struct is replaced, not mutated, so readers holding the
+// old pointer never see it change. A missing id starts from a zero DiskStatus.
+func (c *MutexCache) IncrementTemp(id string, delta int) int {
+	return c.increment(id, tempField, delta)
+}
+
+// IncrementHealth is IncrementTemp for Health.
+func (c *MutexCache) IncrementHealth(id string, delta int) int {
+	return c.increment(id, healthField, delta)
+}
+
+func (c *MutexCache) increment(id string, field func(*DiskStatus) *int, delta int) int {
+	id = c.normalize(id)
+	c.lock()
+	defer c.unlock()
+	current, _ := c.lookup(id)
+	if c.frozen.Load() {
+		if current == nil {
+			return 0
+		}
+		return *field(current)
+	}
+	next := incremented(id, current, field, delta)
+	c.recordUpdate()
+	c.put(id, next, time.Time{})
+	return *field(next)
+}
+
+// UpdateWithTTL stores status so that Get stops returning it once ttl has
+// elapsed. A plain Update clears any previous TTL, and a nil status deletes.
+func (c *MutexCache) UpdateWithTTL(id string, status *DiskStatus, ttl time.Duration) {
+	id = c.normalize(id)
+	c.lock()
+	defer c.unlock()
+	if c.frozen.Load() {
+		return
+	}
+	if status == nil {
+		c.remove(id)
+		return
+	}
+	c.recordUpdate()
+	c.put(id, status, c.now().Add(ttl))
+}
+
+// Touch gives an existing entry a fresh ttl without reading or rewriting its
+// value, and reports whether id was present. An entry that has already
+// expired stays expired.
+func (c *MutexCache) Touch(id string, ttl time.Duration) bool {
+	id = c.normalize(id)
+	c.lock()
+	defer c.unlock()
+	if c.frozen.Load() {
+		return false
+	}
+	if _, ok := c.lookup(id); !ok {
+		return false
+	}
+	e := c.base.data[id]
+	e.expiresAt = c.now().Add(ttl)
+	c.base.data[id] = e
+	return true
+}
+
+// UpdateBatch applies all items under a single lock acquisition. Nil values
+// delete their ids.
+func (c *MutexCache) UpdateBatch(items map[string]*DiskStatus) {
+	c.lock()
+	defer c.unlock()
+	if c.frozen.Load() {
+		return
+	}
+	for id, status := range items {
+		id = c.normalize(id)
+		if status == nil {
+			c.remove(id)
+			continue
+		}
+		c.put(id, status, time.Time{})
+	}
+	c.updates.Add(uint64(len(items)))
+}
+
+func (c *MutexCache) Delete(id string) {
+	id = c.normalize(id)
+	c.lock()
+	defer c.unlock()
+	if c.frozen.Load() {
+		return
+	}
+	c.remove(id)
+}
+
+// GetAndDelete removes id and returns its value in one locked step, so
+// concurrent callers can't both claim the same entry.
+func (c *MutexCache) GetAndDelete(id string) *DiskStatus {
+	id = c.normalize(id)
+	c.lock()
+	defer c.unlock()
+	if c.frozen.Load() {
+		return nil
+	}
+	status, ok := c.lookup(id)
+	if ok {
+		delete(c.base.data, id)
+	}
+	return status
+}
+
+func (c *MutexCache) Clear() {
+	c.lock()
+	defer c.unlock()
+	if c.frozen.Load() {
+		return
+	}
+	for id, e := range c.base.data {
+		c.onEvict.add(id, e.status)
+	}
+	c.base.data = make(map[string]entry)
+}
+
+// ReplaceAll swaps in a copy of m as the whole dataset under one lock, so
+// readers see either the old set or the new one, never a mix. Nil values in m
+// count as absent. Old entries whose ids are not in m are reported to OnEvict.
+func (c *MutexCache) ReplaceAll(m map[string]*DiskStatus) {
+	c.lock()
+	defer c.unlock()
+	if c.frozen.Load() {
+		return
+	}
+	disks := make(map[string]entry, len(m))
+	for id, status := range m {
+		if status == nil {
+			continue
+		}
+		id = c.normalize(id)
+		c.gen++
+		disks[id] = c.next(c.base.data[id], status, time.Time{})
+	}
+	for id, e := range c.base.data {
+		if _, ok := disks[id]; !ok {
+			c.onEvict.add(id, e.status)
+		}
+	}
+	c.base.data = disks
+	c.updates.Add(uint64(len(m)))
+}
+
+// Len includes expired entries that haven't been lazily removed yet.
+func (c *MutexCache) Len() int {
+	c.lock()
+	defer c.unlock()
+	return len(c.base.data)
+}
+
+// Keys skips expired entries, even if they haven't been swept yet.
+func (c *MutexCache) Keys() []string {
+	c.lock()
+	defer c.unlock()
+	now := c.now()
+	keys := make([]string, 0, len(c.base.data))
+	for id, e := range c.base.data {
+		if e.expiresAt.IsZero() || now.Before(e.expiresAt) {
+			keys = append(keys, id)
+		}
+	}
+	return keys
+}
+
+// Snapshot returns a point-in-time copy of all live entries. Values are cloned
+// too, so neither later cache writes nor edits to the result leak across.
+func (c *MutexCache) Snapshot() map[string]*DiskStatus {
+	c.lock()
+	defer c.unlock()
+	now := c.now()
+	snap := make(map[string]*DiskStatus, len(c.base.data))
+	for id, e := range c.base.data {
+		if e.expiresAt.IsZero() || now.Before(e.expiresAt) {
+			snap[id] = cloneStatus(e.status)
+		}
+	}
+	return snap
+}
+
+// Range calls fn for each live entry until fn returns false. The lock is held
+// for the whole walk, so fn must not call back into the cache or it deadlocks.
+func (c *MutexCache) Range(fn func(id string, status *DiskStatus) bool) {
+	c.lock()
+	defer c.unlock()
+	now := c.now()
+	for id, e := range c.base.data {
+		if !e.expiresAt.IsZero() && !now.Before(e.expiresAt) {
+			continue
+		}
+		if !fn(id, e.status) {
+			return
+		}
+	}
+}
+
+// GetOrCompute returns the cached value, or stores and returns compute() on a
+// miss. compute runs at most once per missing key, under the lock. A nil
+// result is returned without being stored.
+func (c *MutexCache) GetOrCompute(id string, compute func() *DiskStatus) *DiskStatus {
+	id = c.normalize(id)
+	c.lock()
+	defer c.unlock()
+	if status, ok := c.lookup(id); ok {
+		c.hits.Add(1)
+		return status
+	}
+	c.misses.Add(1)
+	status := compute()
+	if status == nil || c.frozen.Load() {
+		return status
+	}
+	c.recordUpdate()
+	c.put(id, status, time.Time{})
+	return status
+}
+
+// StartJanitor sweeps expired entries every interval, so keys that are never
+// read again don't pile up. The returned stop waits for the goroutine to exit
+// and is safe to call more than once.
+func (c *MutexCache) StartJanitor(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				c.sweep()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	stop = func() {
+		once.Do(func() { close(done) })
+		<-exited
+	}
+	c.janitorMu.Lock()
+	c.janitors = append(c.janitors, stop)
+	c.janitorMu.Unlock()
+	return stop
+}
+
+// Close stops every janitor started by StartJanitor and waits for them to
+// exit. The cache itself stays usable. It is safe to call more than once.
+func (c *MutexCache) Close() error {
+	c.janitorMu.Lock()
+	janitors := c.janitors
+	c.janitors = nil
+	c.janitorMu.Unlock()
+	for _, stop := range janitors {
+		stop()
+	}
+	return nil
+}
+
+func (c *MutexCache) sweep() {
+	c.lock()
+	defer c.unlock()
+	now := c.now()
+	for id, e := range c.base.data {
+		if !e.expiresAt.IsZero() && !now.Before(e.expiresAt) {
+			delete(c.base.data, id)
+			c.onEvict.add(id, e.status)
+		}
+	}
+}
+
+// 2. RWMutex Cache
+//
+// A wrapper around GenericRWMutexCache adding stats and batch operations.
+type RWMutexCache struct {
+	counters
+	base GenericRWMutexCache[*DiskStatus]
+}
+
+func NewRWMutexCache() *RWMutexCache {
+	return &RWMutexCache{
+		base: GenericRWMutexCache[*DiskStatus]{data: make(map[string]*DiskStatus)},
+	}
+}
+
+func (c *RWMutexCache) Get(id string) *DiskStatus {
+	status, _ := c.base.Get(id)
+	c.recordGet(status)
+	return status
+}
+
+func (c *RWMutexCache) Contains(id string) bool {
+	_, ok := c.base.Get(id)
+	return ok
+}
+
+// GetBatch looks up all ids under a single read lock. Missing keys are absent
+// from the result rather than mapped to nil.
+func (c *RWMutexCache) GetBatch(ids []string) map[string]*DiskStatus {
+	c.base.mu.RLock()
+	defer c.base.mu.RUnlock()
+	result := make(map[string]*DiskStatus, len(ids))
+	for _, id := range ids {
+		status, ok := c.base.data[id]
+		if ok {
+			result[id] = status
+		}
+		c.recordGet(status)
+	}
+	return result
+}
+
+// GetMany is GetBatch returning results by position, nil for misses, so
+// callers can zip them with a parallel slice. It holds the read lock once.
+func (c *RWMutexCache) GetMany(ids []string) []*DiskStatus {
+	c.base.mu.RLock()
+	defer c.base.mu.RUnlock()
+	result := make([]*DiskStatus, len(ids))
+	for i, id := range ids {
+		result[i] = c.base.data[id]
+		c.recordGet(result[i])
+	}
+	return result
+}
+
+// GetCopy is Get returning a private copy, so mutating it can't corrupt the
+// shared cached value.
+func (c *RWMutexCache) GetCopy(id string) *DiskStatus {
+	return cloneStatus(c.Get(id))
+}
+
+func (c *RWMutexCache) Update(id string, status *DiskStatus) {
+	if status == nil {
+		c.Delete(id)
+		return
+	}
+	c.recordUpdate()
+	c.base.Update(id, status)
+}
+
+// UpdateBatch applies all items under a single lock acquisition.
+func (c *RWMutexCache) UpdateBatch(items map[string]*DiskStatus) {
+	c.base.mu.Lock()
+	defer c.base.mu.Unlock()
+	for id, status := range items {
+		if status == nil {
+			delete(c.base.data, id)
+			continue
+		}
+		c.base.data[id] = status
+	}
+	c.updates.Add(uint64(len(items)))
+}
+
+func (c *RWMutexCache) Delete(id string) {
+	c.base.Delete(id)
+}
+
+// GetAndDelete removes id and returns its value in one locked step.
+func (c *RWMutexCache) GetAndDelete(id string) *DiskStatus {
+	c.base.mu.Lock()
+	defer c.base.mu.Unlock()
+	status := c.base.data[id]
+	delete(c.base.data, id)
+	return status
+}
+
+func (c *RWMutexCache) Clear() {
+	c.base.Clear()
+}
+
+// ReplaceAll is MutexCache.ReplaceAll. m is copied, so the caller may keep
+// using it.
+func (c *RWMutexCache) ReplaceAll(m map[string]*DiskStatus) {
+	disks := maps.Clone(m)
+	if disks == nil {
+		disks = make(map[string]*DiskStatus)
+	}
+	// Nil values count as absent, as in MutexCache.ReplaceAll
+	maps.DeleteFunc(disks, func(_ string, status *DiskStatus) bool { return status == nil })
+	c.base.mu.Lock()
+	defer c.base.mu.Unlock()
+	c.base.data = disks
+	c.updates.Add(uint64(len(m)))
+}
+
+func (c *RWMutexCache) Len() int {
+	return c.base.Len()
+}
+
+func (c *RWMutexCache) Keys() []string {
+	var keys []string
+	c.base.Range(func(id string, _ *DiskStatus) bool {
+		keys = append(keys, id)
+		return true
+	})
+	return keys
+}
+
+// Snapshot returns a point-in-time copy of all entries, values included.
+func (c *RWMutexCache) Snapshot() map[string]*DiskStatus {
+	snap := make(map[string]*DiskStatus)
+	c.base.Range(func(id string, status *DiskStatus) bool {
+		snap[id] = cloneStatus(status)
+		return true
+	})
+	return snap
+}
+
+// Range calls fn for each entry until fn returns false. The read lock is held
+// for the whole walk, so fn must not write to the cache or it deadlocks.
+func (c *RWMutexCache) Range(fn func(id string, status *DiskStatus) bool) {
+	c.base.Range(fn)
+}
+
+// GetOrCompute uses double-checked locking: the fast path only takes the read
+// lock, and the miss path re-checks under the write lock so compute runs once.
+func (c *RWMutexCache) GetOrCompute(id string, compute func() *DiskStatus) *DiskStatus {
+	c.base.mu.RLock()
+	status, ok := c.base.data[id]
+	c.base.mu.RUnlock()
+	if ok {
+		c.hits.Add(1)
+		return status
+	}
+
+	c.base.mu.Lock()
+	defer c.base.mu.Unlock()
+	if status, ok := c.base.data[id]; ok {
+		c.hits.Add(1)
+		return status
+	}
+	c.misses.Add(1)
+	status = compute()
+	if status == nil {
+		return nil
+	}
+	c.recordUpdate()
+	c.base.data[id] = status
+	return status
+}
+
+// 3. Sharded Lock Cache
+//
+// A wrapper around GenericShardedCache, which owns the shard table, routing
+// and resizing; the stats, sampling, bloom filter, freezing and struct
+// recycling live here.
+type ShardedCache struct {
+	counters
+	keyNormalizer
+	base      GenericShardedCache[*DiskStatus]
+	sampler   atomic.Pointer[sampler]       // nil unless EnableSampling is on
+	rates     atomic.Pointer[accessTracker] // nil unless TrackAccessRates is on
+	bloom     *bloomFilter                  // nil unless built by NewBloomShardedCache
+	recycle   bool                          // UpdateFields reuses structs, see NewRecyclingShardedCache
+	pool      sync.Pool                     // *DiskStatus structs displaced by UpdateFields
+	pooled    sync.Map                      // id -> pooledEntry for structs stored by UpdateFields
+	frozen    atomic.Bool
+	rebalance uint64 // MaybeRebalance threshold, 0 meaning the default
+}
+
+// pooledEntry is a struct UpdateFields stored, the shard it went into, and
+// that shard's lock count right after storing it. Records left over from a
+// table ResizeShards retired name a shard no longer in use, so they never
+// match.
+type pooledEntry struct {
+	status *DiskStatus
+	shard  *shard[*DiskStatus]
+	locks  uint64
+}
+
+func NewShardedCache() *ShardedCache {
+	return NewShardedCacheWithShards(ShardCount)
+}
+
+// NewShardedCacheWithShards lets the caller trade memory for less contention.
+// n <= 0 falls back to ShardCount.
+func NewShardedCacheWithShards(n int) *ShardedCache {
+	c := &ShardedCache{}
+	c.base.init(n, false)
+	return c
+}
+
+// NewRecyclingShardedCache is NewShardedCacheWithShards whose UpdateFields
+// recycles the structs it displaces. Tracking which of them could still be
+// held by a reader costs every shard lock acquisition an atomic add, reads
+// included, so it only pays off for write-heavy use of UpdateFields.
+func NewRecyclingShardedCache(n int) *ShardedCache {
+	c := &ShardedCache{recycle: true}
+	c.base.init(n, true)
+	return c
+}
+
+// newShardedCacheWithHash is NewShardedCacheWithShards hashing ids with hash
+// instead of fnv, so tests can force chosen keys onto the same shard.
+func newShardedCacheWithHash(n int, hash func(string) uint32) *ShardedCache {
+	c := NewShardedCacheWithShards(n)
+	c.base.hashFunc = hash
+	return c
+}
+
+// NewAutoShardedCache sizes the cache with RecommendedShardCount.
+func NewAutoShardedCache(expectedKeys, goroutines int) *ShardedCache {
+	return NewShardedCacheWithShards(RecommendedShardCount(expectedKeys, goroutines))
+}
+
+const (
+	shardsPerGoroutine = 4    // keeps the odds of two goroutines colliding low
+	minKeysPerShard    = 16   // below this, extra shards are mostly empty maps
+	maxShardCount      = 1024 // past this, contention gains don't pay for memory
+)
+
+// RecommendedShardCount suggests a power-of-two shard count for a cache
+// holding about expectedKeys entries under goroutines concurrent callers:
+// roughly 4 shards per goroutine, but no more than one per 16 keys and never
+// more than 1024. Non-positive goroutines means GOMAXPROCS, and non-positive
+// expectedKeys means unknown, so only the goroutine rule applies.
+func RecommendedShardCount(expectedKeys, goroutines int) int {
+	if goroutines <= 0 {
+		goroutines = runtime.GOMAXPROCS(0)
+	}
+	n := goroutines * shardsPerGoroutine
+	if expectedKeys > 0 {
+		n = min(n, expectedKeys/minKeysPerShard)
+	}
+	n = min(max(n, 1), maxShardCount)
+	// Round up to a power of two
+	p := 1
+	for p < n {
+		p <<= 1
+	}
+	return p
+}
+
+// NewWeightedShardedCache routes keys with shardFunc instead of hashing, for
+// callers whose ids aren't uniformly distributed (e.g. routing by rack prefix).
+// The returned index is taken mod n, so any int is in bounds.
+func NewWeightedShardedCache(n int, shardFunc func(id string) int) *ShardedCache {
+	c := NewShardedCacheWithShards(n)
+	c.base.shardFunc = shardFunc
+	return c
+}
+
+// NewSeededShardedCache hashes ids with maphash under a random per-instance
+// seed rather than fnv, so a client that controls disk ids can't predict
+// which of them collide and pile them all onto one shard.
+func NewSeededShardedCache(n int) *ShardedCache {
+	c := NewShardedCacheWithShards(n)
+	c.base.seeded = true
+	c.base.seed = maphash.MakeSeed()
+	return c
+}
+
+// ResizeShards is GenericShardedCache.ResizeShards. Entries move without
+// their UpdateFields records, so none of them is recycled.
+func (c *ShardedCache) ResizeShards(n int) {
+	c.base.ResizeShards(n)
+	c.pooled.Clear()
+}
+
+// ShardOf reports which shard id is routed to.
+func (c *ShardedCache) ShardOf(id string) int {
+	id = c.normalize(id)
+	return c.base.getShard(id)
+}
+
+// ShardSizes returns the entry count of each shard, to spot skewed routing.
+func (c *ShardedCache) ShardSizes() []int {
+	t := c.base.table.Load()
+	sizes := make([]int, len(t.shards))
+	for i := range t.shards {
+		shard := &t.shards[i]
+		shard.mu.RLock()
+		sizes[i] = len(shard.data)
+		shard.mu.RUnlock()
+	}
+	return sizes
+}
+
+// ShardKeys returns the ids stored in shard i, for debugging routing.
+func (c *ShardedCache) ShardKeys(i int) []string {
+	shard := &c.base.table.Load().shards[i]
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
+	keys := make([]string, 0, len(shard.data))
+	for id := range shard.data {
+		keys = append(keys, id)
+	}
+	return keys
+}
+
+// fnv32a is FNV-1a inlined over the string bytes. Unlike hash/fnv it needs no
+// hasher object and no []byte conversion, so it never allocates.
+func fnv32a(s string) uint32 {
+	const (
+		offset32 = 2166136261
+		prime32  = 16777619
+	)
+	h := uint32(offset32)
+	for i := 0; i < len(s); i++ {
+		h ^= uint32(s[i])
+		h *= prime32
+	}
+	return h
 }
 
-func NewMutexCache() *MutexCache {
-	return &MutexCache{
-		disks: make(map[string]*DiskStatus),
+func (c *ShardedCache) Get(id string) *DiskStatus {
+	id = c.normalize(id)
+	if c.bloom != nil && !c.bloom.mayContain(id) {
+		c.recordGet(nil)
+		return nil
 	}
+	status, _ := c.base.Get(id)
+	c.recordGet(status)
+	if s := c.sampler.Load(); s != nil {
+		s.sample(id)
+	}
+	if t := c.rates.Load(); t != nil {
+		t.sampler.sample(id)
+	}
+	return status
 }
 
-func (c *MutexCache) Get(id string) *DiskStatus {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	return c.disks[id]
+func (c *ShardedCache) Contains(id string) bool {
+	_, ok := c.base.Get(c.normalize(id))
+	return ok
 }
 
-func (c *MutexCache) Update(id string, status *DiskStatus) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	c.disks[id] = status
+// GetBatch groups ids by shard so each shard's read lock is taken at most once.
+// Missing keys are absent from the result rather than mapped to nil; found
+// ones are keyed by the ids as given.
+func (c *ShardedCache) GetBatch(ids []string) map[string]*DiskStatus {
+	t := c.base.table.Load()
+	byShard := make([][]string, len(t.shards))
+	for _, id := range ids {
+		i := c.base.shardIndex(c.normalize(id), len(t.shards))
+		byShard[i] = append(byShard[i], id)
+	}
+	result := make(map[string]*DiskStatus, len(ids))
+	for i, group := range byShard {
+		if len(group) == 0 {
+			continue
+		}
+		shard := &t.shards[i]
+		shard.mu.RLock()
+		for _, id := range group {
+			status, ok := shard.data[c.normalize(id)]
+			if ok {
+				result[id] = status
+			}
+			c.recordGet(status)
+		}
+		shard.mu.RUnlock()
+	}
+	return result
 }
 
-// 2. RWMutex Cache
-type RWMutexCache struct {
-	mu    sync.RWMutex
-	disks map[string]*DiskStatus
+// GetMultiParallel is GetBatch reading each shard's group in its own
+// goroutine. It only beats GetBatch for large batches spread over many shards,
+// where the lookups outweigh starting the goroutines.
+func (c *ShardedCache) GetMultiParallel(ids []string) map[string]*DiskStatus {
+	t := c.base.table.Load()
+	byShard := make([][]string, len(t.shards))
+	for _, id := range ids {
+		i := c.base.shardIndex(c.normalize(id), len(t.shards))
+		byShard[i] = append(byShard[i], id)
+	}
+	// Each goroutine fills its own slot, so no lock is needed until the merge
+	found := make([]map[string]*DiskStatus, len(t.shards))
+	var wg sync.WaitGroup
+	for i, group := range byShard {
+		if len(group) == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, group []string) {
+			defer wg.Done()
+			m := make(map[string]*DiskStatus, len(group))
+			shard := &t.shards[i]
+			shard.mu.RLock()
+			defer shard.mu.RUnlock()
+			for _, id := range group {
+				status, ok := shard.data[c.normalize(id)]
+				if ok {
+					m[id] = status
+				}
+				c.recordGet(status)
+			}
+			found[i] = m
+		}(i, group)
+	}
+	wg.Wait()
+
+	result := make(map[string]*DiskStatus, len(ids))
+	for _, m := range found {
+		for id, status := range m {
+			result[id] = status
+		}
+	}
+	return result
 }
 
-func NewRWMutexCache() *RWMutexCache {
-	return &RWMutexCache{
-		disks: make(map[string]*DiskStatus),
+// GetCopy is Get returning a private copy, so mutating it can't corrupt the
+// shared cached value.
+func (c *ShardedCache) GetCopy(id string) *DiskStatus {
+	return cloneStatus(c.Get(id))
+}
+
+func (c *ShardedCache) Update(id string, status *DiskStatus) {
+	c.UpdateOK(id, status)
+}
+
+// UpdateOK is MutexCache.UpdateOK under the key's shard lock.
+func (c *ShardedCache) UpdateOK(id string, status *DiskStatus) bool {
+	id = c.normalize(id)
+	shard := c.base.lockShard(id)
+	defer shard.mu.Unlock()
+	if c.frozen.Load() {
+		return false
+	}
+	if status != nil {
+		c.recordUpdate()
 	}
+	c.store(shard, id, status)
+	return true
 }
 
-func (c *RWMutexCache) Get(id string) *DiskStatus {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	return c.disks[id]
+// UpdateFields stores a DiskStatus with the given fields. In a cache built by
+// NewRecyclingShardedCache it takes the struct from the cache's pool instead
+// of allocating, and the struct it displaces goes back to the pool only if
+// UpdateFields stored it and nobody has locked the shard since: any Get,
+// Range or other access could have handed the pointer out, and a caller may
+// still hold it. Recycling therefore only kicks in for keys rewritten without
+// reads in between, such as a write-heavy ingest.
+func (c *ShardedCache) UpdateFields(id string, health, temp int) {
+	id = c.normalize(id)
+	shard := c.base.lockShardUncounted(id)
+	defer shard.mu.Unlock()
+	if c.frozen.Load() {
+		return
+	}
+	c.recordUpdate()
+	if !c.recycle {
+		c.store(shard, id, &DiskStatus{ID: id, Health: health, Temp: temp})
+		return
+	}
+	c.bloomAdd(id)
+	old := shard.data[id]
+	if v, ok := c.pooled.Load(id); ok {
+		p := v.(pooledEntry)
+		if p.status == old && p.shard == shard && p.locks == shard.mu.locks.Load() {
+			c.pool.Put(old)
+		}
+	}
+	status, _ := c.pool.Get().(*DiskStatus)
+	if status == nil {
+		status = new(DiskStatus)
+	}
+	*status = DiskStatus{ID: id, Health: health, Temp: temp}
+	shard.data[id] = status
+	c.pooled.Store(id, pooledEntry{status: status, shard: shard, locks: shard.mu.locks.Load()})
 }
 
-func (c *RWMutexCache) Update(id string, status *DiskStatus) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	c.disks[id] = status
+// store sets id to status in shard, or deletes it if status is nil, dropping
+// any record of a struct UpdateFields stored there. The caller must hold
+// shard's lock.
+func (c *ShardedCache) store(shard *shard[*DiskStatus], id string, status *DiskStatus) {
+	if c.recycle {
+		c.pooled.Delete(id)
+	}
+	if status == nil {
+		delete(shard.data, id)
+		return
+	}
+	c.bloomAdd(id)
+	shard.data[id] = status
 }
 
-// 3. Sharded Lock Cache
-const ShardCount = 32
+// CompareAndUpdate stores new only if the current value is the very pointer
+// old (nil meaning absent), and reports whether it did. A nil new deletes.
+func (c *ShardedCache) CompareAndUpdate(id string, old, new *DiskStatus) bool {
+	id = c.normalize(id)
+	shard := c.base.lockShard(id)
+	defer shard.mu.Unlock()
+	if c.frozen.Load() || shard.data[id] != old {
+		return false
+	}
+	if new != nil {
+		c.recordUpdate()
+	}
+	c.store(shard, id, new)
+	return true
+}
 
-type ShardedCache struct {
-	shards [ShardCount]struct {
-		mu    sync.RWMutex
-		disks map[string]*DiskStatus
+// UpdateIfAbsent is MutexCache.UpdateIfAbsent under the key's shard lock.
+func (c *ShardedCache) UpdateIfAbsent(id string, status *DiskStatus) bool {
+	id = c.normalize(id)
+	shard := c.base.lockShard(id)
+	defer shard.mu.Unlock()
+	if c.frozen.Load() {
+		return false
+	}
+	if _, ok := shard.data[id]; ok {
+		return false
+	}
+	if status != nil {
+		c.recordUpdate()
 	}
+	c.store(shard, id, status)
+	return true
 }
 
-func NewShardedCache() *ShardedCache {
-	c := &ShardedCache{}
-	for i := 0; i < ShardCount; i++ {
-		c.shards[i].disks = make(map[string]*DiskStatus)
+// Swap is MutexCache.Swap under the key's shard lock.
+func (c *ShardedCache) Swap(id string, status *DiskStatus) *DiskStatus {
+	id = c.normalize(id)
+	shard := c.base.lockShard(id)
+	defer shard.mu.Unlock()
+	if c.frozen.Load() {
+		return nil
 	}
-	return c
+	old := shard.data[id]
+	if status != nil {
+		c.recordUpdate()
+	}
+	c.store(shard, id, status)
+	return old
 }
 
-func (c *ShardedCache) getShard(id string) int {
-	h := fnv.New32a()
-	h.Write([]byte(id))
-	return int(h.Sum32()) % ShardCount
+// UpdateFunc is MutexCache.UpdateFunc under the key's shard lock.
+func (c *ShardedCache) UpdateFunc(id string, fn func(*DiskStatus) *DiskStatus) {
+	id = c.normalize(id)
+	shard := c.base.lockShard(id)
+	defer shard.mu.Unlock()
+	if c.frozen.Load() {
+		return
+	}
+	next := fn(shard.data[id])
+	if next != nil {
+		c.recordUpdate()
+	}
+	c.store(shard, id, next)
 }
 
-func (c *ShardedCache) Get(id string) *DiskStatus {
-	shard := &c.shards[c.getShard(id)]
-	shard.mu.RLock()
-	defer shard.mu.RUnlock()
-	return shard.disks[id]
+// IncrementTemp is MutexCache.IncrementTemp under the key's shard lock.
+func (c *ShardedCache) IncrementTemp(id string, delta int) int {
+	return c.increment(id, tempField, delta)
 }
 
-func (c *ShardedCache) Update(id string, status *DiskStatus) {
-	shard := &c.shards[c.getShard(id)]
-	shard.mu.Lock()
+// IncrementHealth is IncrementTemp for Health.
+func (c *ShardedCache) IncrementHealth(id string, delta int) int {
+	return c.increment(id, healthField, delta)
+}
+
+func (c *ShardedCache) increment(id string, field func(*DiskStatus) *int, delta int) int {
+	id = c.normalize(id)
+	shard := c.base.lockShard(id)
+	defer shard.mu.Unlock()
+	if c.frozen.Load() {
+		if current := shard.data[id]; current != nil {
+			return *field(current)
+		}
+		return 0
+	}
+	next := incremented(id, shard.data[id], field, delta)
+	c.recordUpdate()
+	c.store(shard, id, next)
+	return *field(next)
+}
+
+// WithShard calls fn with the live map of id's shard under its write lock, so
+// several keys sharing that shard (see ShardOf) can be read and written as one
+// atomic step. fn must not retain the map or call back into the cache, and
+// its writes bypass Stats. While the cache is frozen fn gets a copy, so it can
+// still read but its writes are dropped.
+func (c *ShardedCache) WithShard(id string, fn func(m map[string]*DiskStatus)) {
+	id = c.normalize(id)
+	shard := c.base.lockShard(id)
+	defer shard.mu.Unlock()
+	if c.frozen.Load() {
+		fn(maps.Clone(shard.data))
+		return
+	}
+	// fn may replace or remove any key, so drop the records of stored structs
+	if c.recycle {
+		for id := range shard.data {
+			c.pooled.Delete(id)
+		}
+	}
+	fn(shard.data)
+	// and may have added any, so the filter has to see them all
+	if c.bloom != nil {
+		for id := range shard.data {
+			c.bloom.add(id)
+		}
+	}
+}
+
+// UpdateBatch groups items by shard so each shard lock is taken at most once.
+// Nil values delete their ids.
+func (c *ShardedCache) UpdateBatch(items map[string]*DiskStatus) {
+	type item struct {
+		id     string
+		status *DiskStatus
+	}
+	c.base.resizeMu.RLock()
+	defer c.base.resizeMu.RUnlock()
+	if c.frozen.Load() {
+		return
+	}
+	t := c.base.table.Load()
+	byShard := make([][]item, len(t.shards))
+	for id, status := range items {
+		id = c.normalize(id)
+		i := c.base.shardIndex(id, len(t.shards))
+		byShard[i] = append(byShard[i], item{id, status})
+	}
+	for i, group := range byShard {
+		if len(group) == 0 {
+			continue
+		}
+		shard := &t.shards[i]
+		shard.mu.Lock()
+		for _, it := range group {
+			c.store(shard, it.id, it.status)
+		}
+		shard.mu.Unlock()
+	}
+	c.updates.Add(uint64(len(items)))
+}
+
+// Rename moves oldID's value to newID as one atomic step, overwriting any
+// value newID had, and reports whether oldID existed. Both shard locks are
+// taken in index order, so concurrent Renames in opposite directions can't
+// deadlock.
+func (c *ShardedCache) Rename(oldID, newID string) bool {
+	oldID, newID = c.normalize(oldID), c.normalize(newID)
+	c.base.resizeMu.RLock()
+	defer c.base.resizeMu.RUnlock()
+	t := c.base.table.Load()
+	i, j := c.base.shardIndex(oldID, len(t.shards)), c.base.shardIndex(newID, len(t.shards))
+	from, to := &t.shards[i], &t.shards[j]
+	first, second := from, to
+	if j < i {
+		first, second = to, from
+	}
+	first.mu.Lock()
+	defer first.mu.Unlock()
+	if second != first {
+		second.mu.Lock()
+		defer second.mu.Unlock()
+	}
+	if c.frozen.Load() {
+		return false
+	}
+
+	status, ok := from.data[oldID]
+	if !ok || oldID == newID {
+		return ok
+	}
+	c.store(from, oldID, nil)
+	c.store(to, newID, status)
+	return true
+}
+
+func (c *ShardedCache) Delete(id string) {
+	id = c.normalize(id)
+	shard := c.base.lockShard(id)
+	defer shard.mu.Unlock()
+	if c.frozen.Load() {
+		return
+	}
+	c.store(shard, id, nil)
+}
+
+// GetAndDelete removes id and returns its value in one locked step.
+func (c *ShardedCache) GetAndDelete(id string) *DiskStatus {
+	id = c.normalize(id)
+	shard := c.base.lockShard(id)
+	defer shard.mu.Unlock()
+	if c.frozen.Load() {
+		return nil
+	}
+	status := shard.data[id]
+	c.store(shard, id, nil)
+	return status
+}
+
+// Clear empties each shard in turn; it is not atomic across shards.
+func (c *ShardedCache) Clear() {
+	if c.frozen.Load() {
+		return
+	}
+	c.base.Clear()
+	c.pooled.Clear()
+}
+
+func (c *ShardedCache) Len() int {
+	return c.base.Len()
+}
+
+func (c *ShardedCache) Keys() []string {
+	var keys []string
+	c.base.Range(func(id string, _ *DiskStatus) bool {
+		keys = append(keys, id)
+		return true
+	})
+	return keys
+}
+
+// Snapshot copies all entries, values included. Shards are copied one at a
+// time, so it is only point-in-time per shard.
+func (c *ShardedCache) Snapshot() map[string]*DiskStatus {
+	snap := make(map[string]*DiskStatus)
+	c.base.Range(func(id string, status *DiskStatus) bool {
+		snap[id] = cloneStatus(status)
+		return true
+	})
+	return snap
+}
+
+// Range calls fn for each entry until fn returns false, holding one shard's
+// read lock at a time. fn must not write to the cache or it may deadlock.
+func (c *ShardedCache) Range(fn func(id string, status *DiskStatus) bool) {
+	c.base.Range(fn)
+}
+
+// TempStats summarizes Temp across all entries, one shard's read lock at a
+// time. An empty cache reports all zeros.
+func (c *ShardedCache) TempStats() (min, max int, avg float64, count int) {
+	t := c.base.table.Load()
+	sum := 0
+	for i := range t.shards {
+		shard := &t.shards[i]
+		shard.mu.RLock()
+		for _, status := range shard.data {
+			if count == 0 || status.Temp < min {
+				min = status.Temp
+			}
+			if count == 0 || status.Temp > max {
+				max = status.Temp
+			}
+			sum += status.Temp
+			count++
+		}
+		shard.mu.RUnlock()
+	}
+	if count > 0 {
+		avg = float64(sum) / float64(count)
+	}
+	return min, max, avg, count
+}
+
+// ForEachShardParallel calls fn for every shard at once, one goroutine each,
+// with that shard's map under its read lock, and returns when all are done.
+// It suits per-shard partial aggregates combined afterwards. fn must not
+// modify or retain the map, and must be goroutine-safe if it touches shared
+// state.
+func (c *ShardedCache) ForEachShardParallel(fn func(shard int, m map[string]*DiskStatus)) {
+	t := c.base.table.Load()
+	var wg sync.WaitGroup
+	for i := range t.shards {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			shard := &t.shards[i]
+			shard.mu.RLock()
+			defer shard.mu.RUnlock()
+			fn(i, shard.data)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// GetOrCompute is RWMutexCache.GetOrCompute scoped to the key's shard.
+func (c *ShardedCache) GetOrCompute(id string, compute func() *DiskStatus) *DiskStatus {
+	id = c.normalize(id)
+	shard := c.base.rlockShard(id)
+	status, ok := shard.data[id]
+	shard.mu.RUnlock()
+	if ok {
+		c.hits.Add(1)
+		return status
+	}
+
+	shard = c.base.lockShard(id)
 	defer shard.mu.Unlock()
-	shard.disks[id] = status
+	if status, ok := shard.data[id]; ok {
+		c.hits.Add(1)
+		return status
+	}
+	c.misses.Add(1)
+	status = compute()
+	if status == nil || c.frozen.Load() {
+		return status
+	}
+	c.recordUpdate()
+	c.store(shard, id, status)
+	return status
 }
 
 // 4. sync.Map Cache
+//
+// A wrapper around GenericSyncMapCache adding stats and the atomic
+// read-modify-write operations sync.Map offers.
 type SyncMapCache struct {
-	disks sync.Map
+	counters
+	base GenericSyncMapCache[*DiskStatus]
 }
 
 func NewSyncMapCache() *SyncMapCache {
-	return &SyncMapCache{}
+	c := &SyncMapCache{}
+	c.base.data.Store(new(sync.Map))
+	return c
+}
+
+// Compact is GenericSyncMapCache.Compact.
+func (c *SyncMapCache) Compact() {
+	c.base.Compact()
 }
 
 func (c *SyncMapCache) Get(id string) *DiskStatus {
-	v, ok := c.disks.Load(id)
+	status, _ := c.base.Get(id)
+	c.recordGet(status)
+	return status
+}
+
+func (c *SyncMapCache) Contains(id string) bool {
+	_, ok := c.base.Get(id)
+	return ok
+}
+
+// GetCopy is Get returning a private copy, so mutating it can't corrupt the
+// shared cached value.
+func (c *SyncMapCache) GetCopy(id string) *DiskStatus {
+	return cloneStatus(c.Get(id))
+}
+
+func (c *SyncMapCache) Update(id string, status *DiskStatus) {
+	if status == nil {
+		c.Delete(id)
+		return
+	}
+	c.recordUpdate()
+	c.base.Update(id, status)
+}
+
+// UpdateIfAbsent is MutexCache.UpdateIfAbsent built on LoadOrStore.
+func (c *SyncMapCache) UpdateIfAbsent(id string, status *DiskStatus) bool {
+	m := c.base.lockMap()
+	defer c.base.compactMu.RUnlock()
+	if status == nil {
+		_, ok := m.Load(id)
+		return !ok
+	}
+	if _, loaded := m.LoadOrStore(id, status); loaded {
+		return false
+	}
+	c.recordUpdate()
+	return true
+}
+
+// Swap is MutexCache.Swap built on sync.Map.Swap.
+func (c *SyncMapCache) Swap(id string, status *DiskStatus) *DiskStatus {
+	m := c.base.lockMap()
+	defer c.base.compactMu.RUnlock()
+	var old any
+	var loaded bool
+	if status == nil {
+		old, loaded = m.LoadAndDelete(id)
+	} else {
+		c.recordUpdate()
+		old, loaded = m.Swap(id, status)
+	}
+	if !loaded {
+		return nil
+	}
+	return old.(*DiskStatus)
+}
+
+func (c *SyncMapCache) Delete(id string) {
+	c.base.Delete(id)
+}
+
+func (c *SyncMapCache) GetAndDelete(id string) *DiskStatus {
+	m := c.base.lockMap()
+	defer c.base.compactMu.RUnlock()
+	v, ok := m.LoadAndDelete(id)
 	if !ok {
 		return nil
 	}
 	return v.(*DiskStatus)
 }
 
-func (c *SyncMapCache) Update(id string, status *DiskStatus) {
-	c.disks.Store(id, status)
+func (c *SyncMapCache) Clear() {
+	c.base.Clear()
+}
+
+// Len walks the map, since sync.Map doesn't track its size
+func (c *SyncMapCache) Len() int {
+	return c.base.Len()
+}
+
+func (c *SyncMapCache) Keys() []string {
+	var keys []string
+	c.base.Range(func(id string, _ *DiskStatus) bool {
+		keys = append(keys, id)
+		return true
+	})
+	return keys
+}
+
+// Snapshot copies all entries, values included. Like Range, it may or may not
+// observe writes that race with it.
+func (c *SyncMapCache) Snapshot() map[string]*DiskStatus {
+	snap := make(map[string]*DiskStatus)
+	c.base.Range(func(id string, status *DiskStatus) bool {
+		snap[id] = cloneStatus(status)
+		return true
+	})
+	return snap
+}
+
+// Range has sync.Map.Range semantics: no lock is held, so fn may call back
+// into the cache, but concurrent writes may or may not be observed.
+func (c *SyncMapCache) Range(fn func(id string, status *DiskStatus) bool) {
+	c.base.Range(fn)
 }
 
 // 5. Spinlock Cache
+//
+// A wrapper around GenericSpinLockCache, which owns the lock and its backoff,
+// adding stats and the non-blocking reads.
 type SpinLockCache struct {
-	lock  int32
-	disks map[string]*DiskStatus
+	counters
+	base GenericSpinLockCache[*DiskStatus]
 }
 
 func NewSpinLockCache() *SpinLockCache {
 	return &SpinLockCache{
-		disks: make(map[string]*DiskStatus),
+		base: GenericSpinLockCache[*DiskStatus]{data: make(map[string]*DiskStatus)},
 	}
 }
 
+// NewSpinLockCacheWithBackoff spins at most spins times per acquisition, then
+// parks the goroutine in time.Sleep, doubling from a microsecond up to
+// maxSleep, so heavy contention stops burning CPU on Gosched loops.
+func NewSpinLockCacheWithBackoff(spins int, maxSleep time.Duration) *SpinLockCache {
+	c := NewSpinLockCache()
+	c.base.spins = spins
+	c.base.maxSleep = maxSleep
+	return c
+}
+
 func (c *SpinLockCache) Get(id string) *DiskStatus {
-	// Spin acquire
-	for !atomic.CompareAndSwapInt32(&c.lock, 0, 1) {
-		runtime.Gosched() // Yield CPU to avoid starvation
-	}
-	// Very short critical section
-	status := c.disks[id]
-	atomic.StoreInt32(&c.lock, 0)
+	status, _ := c.base.Get(id)
+	c.recordGet(status)
 	return status
 }
 
+func (c *SpinLockCache) Contains(id string) bool {
+	_, ok := c.base.Get(id)
+	return ok
+}
+
+// TryGet makes a single attempt at the lock instead of spinning. ok reports
+// whether the read happened; (nil, false) means the lock was busy.
+func (c *SpinLockCache) TryGet(id string) (status *DiskStatus, ok bool) {
+	if !atomic.CompareAndSwapInt32(&c.base.lock, 0, 1) {
+		return nil, false
+	}
+	status = c.base.data[id]
+	c.base.release()
+	c.recordGet(status)
+	return status, true
+}
+
+// GetCtx is Get that gives up with ctx.Err() if ctx is done while it is still
+// spinning for the lock.
+func (c *SpinLockCache) GetCtx(ctx context.Context, id string) (*DiskStatus, error) {
+	var b spinBackoff
+	for !atomic.CompareAndSwapInt32(&c.base.lock, 0, 1) {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+		c.base.wait(&b)
+	}
+	status := c.base.data[id]
+	c.base.release()
+	c.recordGet(status)
+	return status, nil
+}
+
+// GetCopy is Get returning a private copy, so mutating it can't corrupt the
+// shared cached value.
+func (c *SpinLockCache) GetCopy(id string) *DiskStatus {
+	return cloneStatus(c.Get(id))
+}
+
 func (c *SpinLockCache) Update(id string, status *DiskStatus) {
-	// Spin acquire
-	for !atomic.CompareAndSwapInt32(&c.lock, 0, 1) {
-		runtime.Gosched()
+	if status == nil {
+		c.Delete(id)
+		return
 	}
-	c.disks[id] = status
-	atomic.StoreInt32(&c.lock, 0)
+	c.base.Update(id, status)
+	c.recordUpdate()
+}
+
+func (c *SpinLockCache) Delete(id string) {
+	c.base.Delete(id)
+}
+
+func (c *SpinLockCache) Clear() {
+	c.base.Clear()
+}
+
+func (c *SpinLockCache) Len() int {
+	return c.base.Len()
+}
+
+func (c *SpinLockCache) Keys() []string {
+	var keys []string
+	c.base.Range(func(id string, _ *DiskStatus) bool {
+		keys = append(keys, id)
+		return true
+	})
+	return keys
+}
+
+// Snapshot returns a point-in-time copy of all entries, values included.
+func (c *SpinLockCache) Snapshot() map[string]*DiskStatus {
+	snap := make(map[string]*DiskStatus)
+	c.base.Range(func(id string, status *DiskStatus) bool {
+		snap[id] = cloneStatus(status)
+		return true
+	})
+	return snap
 }
 
 // 6. Copy-on-Write Cache
+//
+// A wrapper around GenericCOWCache, which counts its own copies, adding stats
+// and zero-copy GetAll.
 type COWCache struct {
-	disks atomic.Value // stores map[string]*DiskStatus
+	counters
+	base GenericCOWCache[*DiskStatus]
 }
 
 func NewCOWCache() *COWCache {
 	c := &COWCache{}
-	c.disks.Store(make(map[string]*DiskStatus))
+	m := make(map[string]*DiskStatus)
+	c.base.data.Store(&m)
 	return c
 }
 
 func (c *COWCache) Get(id string) *DiskStatus {
-	m := c.disks.Load().(map[string]*DiskStatus)
-	return m[id] // Read is completely lock-free!
+	status, _ := c.base.Get(id)
+	c.recordGet(status)
+	return status
+}
+
+func (c *COWCache) Contains(id string) bool {
+	_, ok := c.base.Get(id)
+	return ok
+}
+
+// GetCopy is Get returning a private copy, so mutating it can't corrupt the
+// shared cached value.
+func (c *COWCache) GetCopy(id string) *DiskStatus {
+	return cloneStatus(c.Get(id))
 }
 
 func (c *COWCache) Update(id string, status *DiskStatus) {
-	// Note: In production, you'd want a mutex here to prevent concurrent writers
-	// from creating conflicting copies. For simplicity, we use Store directly.
-	old := c.disks.Load().(map[string]*DiskStatus)
-	// Copy entire map (write becomes slow)
-	new := make(map[string]*DiskStatus, len(old)+1)
-	for k, v := range old {
-		new[k] = v
+	if status == nil {
+		c.Delete(id)
+		return
+	}
+	c.recordUpdate()
+	c.base.Update(id, status)
+}
+
+// Stats adds copy cost to the usual counters: CopiedEntries/Copies is the
+// average number of entries each write had to copy.
+func (c *COWCache) Stats() Stats {
+	s := c.counters.Stats()
+	s.Copies = c.base.copies.Load()
+	s.CopiedEntries = c.base.copiedEntries.Load()
+	return s
+}
+
+func (c *COWCache) Delete(id string) {
+	c.base.Delete(id)
+}
+
+func (c *COWCache) Clear() {
+	c.base.Clear()
+}
+
+func (c *COWCache) Len() int {
+	return c.base.Len()
+}
+
+func (c *COWCache) Keys() []string {
+	m := c.base.load()
+	keys := make([]string, 0, len(m))
+	for id := range m {
+		keys = append(keys, id)
 	}
-	new[id] = status
-	c.disks.Store(new)
+	return keys
+}
+
+// GetAll returns the whole cache as of the call. With safe false it is the
+// stored map itself, at no cost: later writes install a new map and never
+// touch it, but the caller must treat it and its values as read-only. With
+// safe true it is a Snapshot the caller owns.
+func (c *COWCache) GetAll(safe bool) map[string]*DiskStatus {
+	if safe {
+		return c.Snapshot()
+	}
+	return c.base.load()
+}
+
+// Snapshot is consistent for free since the stored map is immutable, but it
+// still copies so callers can't alias (and mutate) the live map or values.
+func (c *COWCache) Snapshot() map[string]*DiskStatus {
+	m := c.base.load()
+	snap := make(map[string]*DiskStatus, len(m))
+	for id, status := range m {
+		snap[id] = cloneStatus(status)
+	}
+	return snap
 }
 
 // 7. Hybrid Cache (Sharded + COW)
+//
+// A wrapper around GenericHybridCache, which owns both tiers and hot-shard
+// demotion, adding stats and promotion of frequently read cold entries.
 type HybridCache struct {
-	// Hot data: sharded lock protection
-	hot [32]struct {
-		mu   sync.RWMutex
-		data map[string]*DiskStatus
-	}
-	// Cold data: COW (history records, rarely updated)
-	cold atomic.Value
+	counters
+	base GenericHybridCache[*DiskStatus]
+	// Cold reads per key, so frequently read cold entries get promoted
+	promoteAfter int32
+	coldHits     sync.Map // id -> *atomic.Int32
 }
 
+// DefaultPromoteAfter is how many cold reads promote an entry to the hot tier.
+const DefaultPromoteAfter = 3
+
 func NewHybridCache() *HybridCache {
-	c := &HybridCache{}
-	for i := 0; i < 32; i++ {
-		c.hot[i].data = make(map[string]*DiskStatus)
-	}
-	c.cold.Store(make(map[string]*DiskStatus))
-	return c
+	return NewHybridCacheWithPromotion(DefaultPromoteAfter)
+}
+
+// NewHybridCacheWithPromotion copies a cold entry into its hot shard once it
+// has been read promoteAfter times from the cold tier. promoteAfter <= 0
+// disables promotion.
+func NewHybridCacheWithPromotion(promoteAfter int) *HybridCache {
+	return NewHybridCacheWithLimits(promoteAfter, 0)
 }
 
-func (c *HybridCache) getShard(id string) int {
-	h := fnv.New32a()
-	h.Write([]byte(id))
-	return int(h.Sum32()) % 32
+// NewHybridCacheWithLimits is NewHybridCacheWithPromotion that also caps each
+// hot shard at hotShardLimit entries, demoting the oldest inserted entries to
+// the cold tier once a shard grows past it. hotShardLimit <= 0 means no limit.
+func NewHybridCacheWithLimits(promoteAfter, hotShardLimit int) *HybridCache {
+	c := &HybridCache{promoteAfter: int32(promoteAfter)}
+	c.base.init(hotShardLimit)
+	return c
 }
 
 func (c *HybridCache) Get(id string) *DiskStatus {
 	// Try hot cache first
-	shard := &c.hot[c.getShard(id)]
+	shard := &c.base.hot[c.base.getShard(id)]
 	shard.mu.RLock()
 	status := shard.data[id]
 	shard.mu.RUnlock()
 
 	if status != nil {
+		c.recordGet(status)
 		return status
 	}
 
 	// Fallback to cold cache
-	m := c.cold.Load().(map[string]*DiskStatus)
-	return m[id]
+	status = c.base.loadCold()[id]
+	c.recordGet(status)
+	if status != nil && c.promoteAfter > 0 {
+		c.maybePromote(id, status)
+	}
+	return status
 }
 
-func (c *HybridCache) Update(id string, status *DiskStatus) {
-	shard := &c.hot[c.getShard(id)]
+// maybePromote counts a cold read of id and, once it reaches promoteAfter,
+// copies the entry into its hot shard so later reads take the fast path.
+func (c *HybridCache) maybePromote(id string, status *DiskStatus) {
+	v, ok := c.coldHits.Load(id)
+	if !ok {
+		v, _ = c.coldHits.LoadOrStore(id, new(atomic.Int32))
+	}
+	if v.(*atomic.Int32).Add(1) < c.promoteAfter {
+		return
+	}
+	c.coldHits.Delete(id)
+
+	shard := &c.base.hot[c.base.getShard(id)]
 	shard.mu.Lock()
-	shard.data[id] = status
+	// Don't clobber a hot write that raced with us, and only copy status if
+	// it is still the cold value: UpdateCold and Delete change the cold tier
+	// before taking this lock, so a stale status is caught here
+	_, hot := shard.data[id]
+	if !hot && c.base.loadCold()[id] == status {
+		shard.put(id, status)
+	}
+	over := c.base.hotLimit > 0 && len(shard.data) > c.base.hotLimit
 	shard.mu.Unlock()
+	if over {
+		c.base.demoteOverflow(shard)
+	}
+}
+
+func (c *HybridCache) Contains(id string) bool {
+	_, ok := c.base.Get(id)
+	return ok
+}
+
+// GetCopy is Get returning a private copy, so mutating it can't corrupt the
+// shared cached value.
+func (c *HybridCache) GetCopy(id string) *DiskStatus {
+	return cloneStatus(c.Get(id))
+}
+
+func (c *HybridCache) Update(id string, status *DiskStatus) {
+	if status == nil {
+		c.Delete(id)
+		return
+	}
+	c.recordUpdate()
+	c.base.Update(id, status)
+}
+
+// Demote is GenericHybridCache.Demote.
+func (c *HybridCache) Demote(id string) {
+	c.base.Demote(id)
 }
 
+// UpdateCold writes status straight to the cold tier. A nil status deletes id
+// from both tiers.
 func (c *HybridCache) UpdateCold(id string, status *DiskStatus) {
-	// Note: In production, you'd want a mutex here to prevent concurrent writers
-	old := c.cold.Load().(map[string]*DiskStatus)
-	new := make(map[string]*DiskStatus, len(old)+1)
-	for k, v := range old {
-		new[k] = v
-	}
-	new[id] = status
-	c.cold.Store(new)
+	if status == nil {
+		c.Delete(id)
+		return
+	}
+	c.base.coldMu.Lock()
+	defer c.base.coldMu.Unlock()
+	c.recordUpdate()
+	old := c.base.editColdLocked(func(m map[string]*DiskStatus) { m[id] = status })
+
+	// A promoted copy still holds the old cold pointer; refresh it so the hot
+	// tier doesn't shadow this write with stale data
+	if prev := old[id]; prev != nil {
+		c.refreshPromoted(id, prev, status)
+	}
+}
+
+// refreshPromoted replaces id's hot value with status if it is still prev, a
+// copy promoted from the cold tier.
+func (c *HybridCache) refreshPromoted(id string, prev, status *DiskStatus) {
+	shard := &c.base.hot[c.base.getShard(id)]
+	shard.mu.Lock()
+	if shard.data[id] == prev {
+		shard.data[id] = status
+	}
+	shard.mu.Unlock()
+}
+
+// BulkLoadCold merges m into the cold tier with a single copy of the cold
+// map, instead of the copy per entry a loop of UpdateCold would make. A nil
+// value deletes its id from both tiers, as UpdateCold does.
+func (c *HybridCache) BulkLoadCold(m map[string]*DiskStatus) {
+	if len(m) == 0 {
+		return
+	}
+	c.base.coldMu.Lock()
+	defer c.base.coldMu.Unlock()
+	old := c.base.editColdLocked(func(cold map[string]*DiskStatus) {
+		for id, status := range m {
+			if status == nil {
+				delete(cold, id)
+			} else {
+				cold[id] = status
+			}
+		}
+	})
+
+	// Refresh promoted copies, as UpdateCold does. Deleted ids go from the hot
+	// tier after the cold one, in Delete's order.
+	for id, status := range m {
+		if status == nil {
+			shard := &c.base.hot[c.base.getShard(id)]
+			shard.mu.Lock()
+			shard.remove(id)
+			shard.mu.Unlock()
+			c.coldHits.Delete(id)
+			continue
+		}
+		c.recordUpdate()
+		if prev := old[id]; prev != nil {
+			c.refreshPromoted(id, prev, status)
+		}
+	}
+}
+
+// Delete removes id from both tiers so a stale cold entry can't resurface
+// once the hot entry is gone.
+func (c *HybridCache) Delete(id string) {
+	c.base.Delete(id)
+	c.coldHits.Delete(id)
+}
+
+// Len counts distinct ids across both tiers; a cold entry shadowed by a hot
+// one is only counted once.
+func (c *HybridCache) Len() int {
+	return c.base.Len()
+}
+
+// Keys returns the union of both tiers without duplicates.
+func (c *HybridCache) Keys() []string {
+	var keys []string
+	c.base.Range(func(id string, _ *DiskStatus) bool {
+		keys = append(keys, id)
+		return true
+	})
+	return keys
+}
+
+func (c *HybridCache) Clear() {
+	c.base.Clear()
+	c.coldHits.Clear()
+}
+
+// Snapshot merges both tiers, with hot entries shadowing cold ones.
+func (c *HybridCache) Snapshot() map[string]*DiskStatus {
+	snap := make(map[string]*DiskStatus)
+	c.base.Range(func(id string, status *DiskStatus) bool {
+		snap[id] = cloneStatus(status)
+		return true
+	})
+	return snap
 }
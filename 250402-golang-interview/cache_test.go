@@ -1,9 +1,17 @@
 package cache
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"hash/fnv"
+	"maps"
+	"math/rand"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 const (
@@ -25,9 +33,25 @@ func prepareTestData() []*DiskStatus {
 	return data
 }
 
+// Cache implementations under test, in the order they appear in the article
+var implementations = []struct {
+	name string
+	new  func() Cache
+}{
+	{"Mutex", func() Cache { return NewMutexCache() }},
+	{"RWMutex", func() Cache { return NewRWMutexCache() }},
+	{"Sharded", func() Cache { return NewShardedCache() }},
+	{"SyncMap", func() Cache { return NewSyncMapCache() }},
+	{"SpinLock", func() Cache { return NewSpinLockCache() }},
+	{"COW", func() Cache { return NewCOWCache() }},
+	{"Hybrid", func() Cache { return NewHybridCache() }},
+	{"StripedSyncMap", func() Cache { return NewStripedSyncMapCache() }},
+	{"SeqLock", func() Cache { return NewSeqLockCache() }},
+	{"ShardedCOW", func() Cache { return NewShardedCOWCache() }},
+}
+
 // Initialize cache with test data
-func initMutexCache() *MutexCache {
-	c := NewMutexCache()
+func initCache(c Cache) Cache {
 	data := prepareTestData()
 	for _, status := range data {
 		c.Update(status.ID, status)
@@ -35,489 +59,2647 @@ func initMutexCache() *MutexCache {
 	return c
 }
 
-func initRWMutexCache() *RWMutexCache {
-	c := NewRWMutexCache()
-	data := prepareTestData()
-	for _, status := range data {
-		c.Update(status.ID, status)
+// Benchmark: Read-heavy workload (100:1 read:write)
+func BenchmarkRead(b *testing.B) {
+	for _, impl := range implementations {
+		b.Run(impl.name, func(b *testing.B) {
+			c := initCache(impl.new())
+			b.ResetTimer()
+			b.SetParallelism(benchParallel)
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					id := fmt.Sprintf("disk-%d", i%numKeys)
+					c.Get(id)
+					i++
+				}
+			})
+		})
 	}
-	return c
 }
 
-func initShardedCache() *ShardedCache {
-	c := NewShardedCache()
-	data := prepareTestData()
-	for _, status := range data {
-		c.Update(status.ID, status)
+// Benchmark: Write-heavy workload
+func BenchmarkWrite(b *testing.B) {
+	for _, impl := range implementations {
+		b.Run(impl.name, func(b *testing.B) {
+			c := initCache(impl.new())
+			b.ResetTimer()
+			b.SetParallelism(benchParallel)
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					id := fmt.Sprintf("disk-%d", i%numKeys)
+					status := &DiskStatus{ID: id, Health: 100, Temp: 45}
+					c.Update(id, status)
+					i++
+				}
+			})
+		})
 	}
-	return c
 }
 
-func initSyncMapCache() *SyncMapCache {
-	c := NewSyncMapCache()
-	data := prepareTestData()
-	for _, status := range data {
-		c.Update(status.ID, status)
+// Benchmark: Mixed workload (100:1 read:write ratio)
+func BenchmarkMixed(b *testing.B) {
+	for _, impl := range implementations {
+		b.Run(impl.name, func(b *testing.B) {
+			c := initCache(impl.new())
+			b.ResetTimer()
+			b.SetParallelism(benchParallel)
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					id := fmt.Sprintf("disk-%d", i%numKeys)
+					if i%readRatio == 0 {
+						status := &DiskStatus{ID: id, Health: 100, Temp: 45}
+						c.Update(id, status)
+					} else {
+						c.Get(id)
+					}
+					i++
+				}
+			})
+		})
 	}
-	return c
 }
 
-func initSpinLockCache() *SpinLockCache {
-	c := NewSpinLockCache()
-	data := prepareTestData()
-	for _, status := range data {
-		c.Update(status.ID, status)
-	}
-	return c
+// zipfS is the skew of the Zipfian benchmarks: with s = 1.1 over numKeys keys,
+// the hottest key gets about a sixth of all accesses and the top 10 over a
+// third, so a few shards (or one) take most of the traffic.
+const zipfS = 1.1
+
+var zipfSeed atomic.Int64
+
+// newZipf returns a Zipfian generator over key indexes [0, numKeys). Each
+// goroutine needs its own, since rand.Zipf isn't safe for concurrent use.
+func newZipf() *rand.Zipf {
+	r := rand.New(rand.NewSource(zipfSeed.Add(1)))
+	return rand.NewZipf(r, zipfS, 1, numKeys-1)
 }
 
-func initCOWCache() *COWCache {
-	c := NewCOWCache()
-	data := prepareTestData()
-	for _, status := range data {
-		c.Update(status.ID, status)
+// Benchmark: Read-heavy workload with Zipfian key popularity
+func BenchmarkZipfRead(b *testing.B) {
+	for _, impl := range implementations {
+		b.Run(impl.name, func(b *testing.B) {
+			c := initCache(impl.new())
+			b.ResetTimer()
+			b.SetParallelism(benchParallel)
+			b.RunParallel(func(pb *testing.PB) {
+				zipf := newZipf()
+				for pb.Next() {
+					c.Get(fmt.Sprintf("disk-%d", zipf.Uint64()))
+				}
+			})
+		})
 	}
-	return c
 }
 
-func initHybridCache() *HybridCache {
-	c := NewHybridCache()
-	data := prepareTestData()
-	for _, status := range data {
-		c.Update(status.ID, status)
+// Benchmark: Mixed workload (100:1 read:write) with Zipfian key popularity,
+// so the writes also land on the hot keys
+func BenchmarkZipfMixed(b *testing.B) {
+	for _, impl := range implementations {
+		b.Run(impl.name, func(b *testing.B) {
+			c := initCache(impl.new())
+			b.ResetTimer()
+			b.SetParallelism(benchParallel)
+			b.RunParallel(func(pb *testing.PB) {
+				zipf := newZipf()
+				i := 0
+				for pb.Next() {
+					id := fmt.Sprintf("disk-%d", zipf.Uint64())
+					if i%readRatio == 0 {
+						status := &DiskStatus{ID: id, Health: 100, Temp: 45}
+						c.Update(id, status)
+					} else {
+						c.Get(id)
+					}
+					i++
+				}
+			})
+		})
 	}
-	return c
 }
 
-// Benchmark: Read-heavy workload (100:1 read:write)
-func BenchmarkMutexRead(b *testing.B) {
-	c := initMutexCache()
-	b.ResetTimer()
-	b.SetParallelism(benchParallel)
-	b.RunParallel(func(pb *testing.PB) {
-		i := 0
-		for pb.Next() {
-			id := fmt.Sprintf("disk-%d", i%numKeys)
-			c.Get(id)
-			i++
-		}
-	})
-}
+// Basic correctness tests
+func TestCacheCorrectness(t *testing.T) {
+	status := &DiskStatus{ID: "disk-1", Health: 100, Temp: 45}
 
-func BenchmarkRWMutexRead(b *testing.B) {
-	c := initRWMutexCache()
-	b.ResetTimer()
-	b.SetParallelism(benchParallel)
-	b.RunParallel(func(pb *testing.PB) {
-		i := 0
-		for pb.Next() {
-			id := fmt.Sprintf("disk-%d", i%numKeys)
-			c.Get(id)
-			i++
-		}
-	})
+	for _, impl := range implementations {
+		t.Run(impl.name, func(t *testing.T) {
+			c := impl.new()
+			c.Update("disk-1", status)
+			got := c.Get("disk-1")
+			if got == nil || got.ID != "disk-1" {
+				t.Errorf("expected disk-1, got %v", got)
+			}
+		})
+	}
 }
 
-func BenchmarkShardedRead(b *testing.B) {
-	c := initShardedCache()
-	b.ResetTimer()
-	b.SetParallelism(benchParallel)
-	b.RunParallel(func(pb *testing.PB) {
-		i := 0
-		for pb.Next() {
-			id := fmt.Sprintf("disk-%d", i%numKeys)
-			c.Get(id)
-			i++
+// Concurrent correctness test
+func TestCacheConcurrency(t *testing.T) {
+	const goroutines = 100
+	const operations = 1000
+
+	t.Run("ShardedCache", func(t *testing.T) {
+		c := NewShardedCache()
+		var wg sync.WaitGroup
+		wg.Add(goroutines)
+
+		for i := 0; i < goroutines; i++ {
+			go func(id int) {
+				defer wg.Done()
+				for j := 0; j < operations; j++ {
+					key := fmt.Sprintf("disk-%d", j%100)
+					status := &DiskStatus{ID: key, Health: 100, Temp: 45}
+					c.Update(key, status)
+					c.Get(key)
+				}
+			}(i)
 		}
+
+		wg.Wait()
 	})
-}
 
-func BenchmarkSyncMapRead(b *testing.B) {
-	c := initSyncMapCache()
-	b.ResetTimer()
-	b.SetParallelism(benchParallel)
-	b.RunParallel(func(pb *testing.PB) {
-		i := 0
-		for pb.Next() {
-			id := fmt.Sprintf("disk-%d", i%numKeys)
-			c.Get(id)
-			i++
+	t.Run("COWCache", func(t *testing.T) {
+		c := NewCOWCache()
+		var wg sync.WaitGroup
+		wg.Add(goroutines)
+
+		for i := 0; i < goroutines; i++ {
+			go func(id int) {
+				defer wg.Done()
+				key := fmt.Sprintf("disk-%d", id)
+				c.Update(key, &DiskStatus{ID: key, Health: 100, Temp: 45})
+			}(i)
 		}
-	})
-}
 
-func BenchmarkSpinLockRead(b *testing.B) {
-	c := initSpinLockCache()
-	b.ResetTimer()
-	b.SetParallelism(benchParallel)
-	b.RunParallel(func(pb *testing.PB) {
-		i := 0
-		for pb.Next() {
-			id := fmt.Sprintf("disk-%d", i%numKeys)
-			c.Get(id)
-			i++
+		wg.Wait()
+		if got := c.Len(); got != goroutines {
+			t.Errorf("expected %d entries, got %d (lost writes)", goroutines, got)
 		}
 	})
-}
 
-func BenchmarkCOWRead(b *testing.B) {
-	c := initCOWCache()
-	b.ResetTimer()
-	b.SetParallelism(benchParallel)
-	b.RunParallel(func(pb *testing.PB) {
-		i := 0
-		for pb.Next() {
-			id := fmt.Sprintf("disk-%d", i%numKeys)
-			c.Get(id)
-			i++
+	t.Run("HybridCacheCold", func(t *testing.T) {
+		c := NewHybridCache()
+		var wg sync.WaitGroup
+		wg.Add(goroutines)
+
+		for i := 0; i < goroutines; i++ {
+			go func(id int) {
+				defer wg.Done()
+				key := fmt.Sprintf("disk-%d", id)
+				c.UpdateCold(key, &DiskStatus{ID: key, Health: 100, Temp: 45})
+			}(i)
 		}
-	})
-}
 
-func BenchmarkHybridRead(b *testing.B) {
-	c := initHybridCache()
-	b.ResetTimer()
-	b.SetParallelism(benchParallel)
-	b.RunParallel(func(pb *testing.PB) {
-		i := 0
-		for pb.Next() {
-			id := fmt.Sprintf("disk-%d", i%numKeys)
-			c.Get(id)
-			i++
+		wg.Wait()
+		for i := 0; i < goroutines; i++ {
+			key := fmt.Sprintf("disk-%d", i)
+			if c.Get(key) == nil {
+				t.Errorf("cold write for %s was lost", key)
+			}
 		}
 	})
 }
 
-// Benchmark: Write-heavy workload
-func BenchmarkMutexWrite(b *testing.B) {
-	c := initMutexCache()
-	b.ResetTimer()
-	b.SetParallelism(benchParallel)
-	b.RunParallel(func(pb *testing.PB) {
-		i := 0
-		for pb.Next() {
-			id := fmt.Sprintf("disk-%d", i%numKeys)
-			status := &DiskStatus{ID: id, Health: 100, Temp: 45}
-			c.Update(id, status)
-			i++
+// Delete removes entries
+func TestCacheDelete(t *testing.T) {
+	status := &DiskStatus{ID: "disk-1", Health: 100, Temp: 45}
+
+	t.Run("MutexCache", func(t *testing.T) {
+		c := NewMutexCache()
+		c.Update("disk-1", status)
+		c.Delete("disk-1")
+		if got := c.Get("disk-1"); got != nil {
+			t.Errorf("expected nil after delete, got %v", got)
 		}
 	})
-}
 
-func BenchmarkRWMutexWrite(b *testing.B) {
-	c := initRWMutexCache()
-	b.ResetTimer()
-	b.SetParallelism(benchParallel)
-	b.RunParallel(func(pb *testing.PB) {
-		i := 0
-		for pb.Next() {
-			id := fmt.Sprintf("disk-%d", i%numKeys)
-			status := &DiskStatus{ID: id, Health: 100, Temp: 45}
-			c.Update(id, status)
-			i++
+	t.Run("RWMutexCache", func(t *testing.T) {
+		c := NewRWMutexCache()
+		c.Update("disk-1", status)
+		c.Delete("disk-1")
+		if got := c.Get("disk-1"); got != nil {
+			t.Errorf("expected nil after delete, got %v", got)
 		}
 	})
-}
 
-func BenchmarkShardedWrite(b *testing.B) {
-	c := initShardedCache()
-	b.ResetTimer()
-	b.SetParallelism(benchParallel)
-	b.RunParallel(func(pb *testing.PB) {
-		i := 0
-		for pb.Next() {
-			id := fmt.Sprintf("disk-%d", i%numKeys)
-			status := &DiskStatus{ID: id, Health: 100, Temp: 45}
-			c.Update(id, status)
-			i++
+	t.Run("ShardedCache", func(t *testing.T) {
+		c := NewShardedCache()
+		c.Update("disk-1", status)
+		c.Delete("disk-1")
+		if got := c.Get("disk-1"); got != nil {
+			t.Errorf("expected nil after delete, got %v", got)
 		}
 	})
-}
 
-func BenchmarkSyncMapWrite(b *testing.B) {
-	c := initSyncMapCache()
-	b.ResetTimer()
-	b.SetParallelism(benchParallel)
-	b.RunParallel(func(pb *testing.PB) {
-		i := 0
-		for pb.Next() {
-			id := fmt.Sprintf("disk-%d", i%numKeys)
-			status := &DiskStatus{ID: id, Health: 100, Temp: 45}
-			c.Update(id, status)
-			i++
+	t.Run("SyncMapCache", func(t *testing.T) {
+		c := NewSyncMapCache()
+		c.Update("disk-1", status)
+		c.Delete("disk-1")
+		if got := c.Get("disk-1"); got != nil {
+			t.Errorf("expected nil after delete, got %v", got)
 		}
 	})
-}
 
-func BenchmarkSpinLockWrite(b *testing.B) {
-	c := initSpinLockCache()
-	b.ResetTimer()
-	b.SetParallelism(benchParallel)
-	b.RunParallel(func(pb *testing.PB) {
-		i := 0
-		for pb.Next() {
-			id := fmt.Sprintf("disk-%d", i%numKeys)
-			status := &DiskStatus{ID: id, Health: 100, Temp: 45}
-			c.Update(id, status)
-			i++
+	t.Run("SpinLockCache", func(t *testing.T) {
+		c := NewSpinLockCache()
+		c.Update("disk-1", status)
+		c.Delete("disk-1")
+		if got := c.Get("disk-1"); got != nil {
+			t.Errorf("expected nil after delete, got %v", got)
 		}
 	})
-}
 
-func BenchmarkCOWWrite(b *testing.B) {
-	c := initCOWCache()
-	b.ResetTimer()
-	b.SetParallelism(benchParallel)
-	b.RunParallel(func(pb *testing.PB) {
-		i := 0
-		for pb.Next() {
-			id := fmt.Sprintf("disk-%d", i%numKeys)
-			status := &DiskStatus{ID: id, Health: 100, Temp: 45}
-			c.Update(id, status)
-			i++
+	t.Run("COWCache", func(t *testing.T) {
+		c := NewCOWCache()
+		c.Update("disk-1", status)
+		c.Update("disk-2", &DiskStatus{ID: "disk-2"})
+		c.Delete("disk-1")
+		if got := c.Get("disk-1"); got != nil {
+			t.Errorf("expected nil after delete, got %v", got)
+		}
+		if got := c.Get("disk-2"); got == nil {
+			t.Errorf("expected disk-2 to survive delete of disk-1")
 		}
 	})
-}
 
-func BenchmarkHybridWrite(b *testing.B) {
-	c := initHybridCache()
-	b.ResetTimer()
-	b.SetParallelism(benchParallel)
-	b.RunParallel(func(pb *testing.PB) {
-		i := 0
-		for pb.Next() {
-			id := fmt.Sprintf("disk-%d", i%numKeys)
-			status := &DiskStatus{ID: id, Health: 100, Temp: 45}
-			c.Update(id, status)
-			i++
+	t.Run("HybridCache", func(t *testing.T) {
+		c := NewHybridCache()
+		c.UpdateCold("disk-1", &DiskStatus{ID: "disk-1", Health: 50})
+		c.Update("disk-1", status)
+		c.Delete("disk-1")
+		if got := c.Get("disk-1"); got != nil {
+			t.Errorf("expected nil after delete, got %v (stale cold entry?)", got)
 		}
 	})
 }
 
-// Benchmark: Mixed workload (100:1 read:write ratio)
-func BenchmarkMutexMixed(b *testing.B) {
-	c := initMutexCache()
-	b.ResetTimer()
-	b.SetParallelism(benchParallel)
-	b.RunParallel(func(pb *testing.PB) {
-		i := 0
-		for pb.Next() {
-			id := fmt.Sprintf("disk-%d", i%numKeys)
-			if i%readRatio == 0 {
-				status := &DiskStatus{ID: id, Health: 100, Temp: 45}
-				c.Update(id, status)
-			} else {
-				c.Get(id)
+func TestSyncMapCacheCompact(t *testing.T) {
+	const keys, cycles = 200, 20
+	c := NewSyncMapCache()
+	for cycle := 0; cycle < cycles; cycle++ {
+		for i := 0; i < keys; i++ {
+			id := fmt.Sprintf("disk-%d-%d", cycle, i)
+			c.Update(id, &DiskStatus{ID: id})
+			if i%2 == 1 {
+				c.Delete(id)
 			}
-			i++
 		}
-	})
-}
+	}
+
+	// Writes racing with Compact must land in the new map
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < keys; i++ {
+				id := fmt.Sprintf("racing-%d-%d", w, i)
+				c.Update(id, &DiskStatus{ID: id})
+			}
+		}(w)
+	}
+	for i := 0; i < 5; i++ {
+		c.Compact()
+	}
+	wg.Wait()
 
-func BenchmarkRWMutexMixed(b *testing.B) {
-	c := initRWMutexCache()
-	b.ResetTimer()
-	b.SetParallelism(benchParallel)
-	b.RunParallel(func(pb *testing.PB) {
-		i := 0
-		for pb.Next() {
-			id := fmt.Sprintf("disk-%d", i%numKeys)
-			if i%readRatio == 0 {
-				status := &DiskStatus{ID: id, Health: 100, Temp: 45}
-				c.Update(id, status)
-			} else {
-				c.Get(id)
+	if got, want := c.Len(), cycles*keys/2+4*keys; got != want {
+		t.Errorf("expected Len %d, got %d", want, got)
+	}
+	for cycle := 0; cycle < cycles; cycle++ {
+		for i := 0; i < keys; i++ {
+			id := fmt.Sprintf("disk-%d-%d", cycle, i)
+			if got := c.Contains(id); got != (i%2 == 0) {
+				t.Fatalf("expected Contains(%s) %v after Compact, got %v", id, i%2 == 0, got)
 			}
-			i++
 		}
-	})
+	}
+	for w := 0; w < 4; w++ {
+		for i := 0; i < keys; i++ {
+			if id := fmt.Sprintf("racing-%d-%d", w, i); c.Get(id) == nil {
+				t.Fatalf("expected %s written during Compact to survive", id)
+			}
+		}
+	}
 }
 
-func BenchmarkShardedMixed(b *testing.B) {
-	c := initShardedCache()
-	b.ResetTimer()
-	b.SetParallelism(benchParallel)
-	b.RunParallel(func(pb *testing.PB) {
-		i := 0
-		for pb.Next() {
-			id := fmt.Sprintf("disk-%d", i%numKeys)
-			if i%readRatio == 0 {
-				status := &DiskStatus{ID: id, Health: 100, Temp: 45}
-				c.Update(id, status)
-			} else {
-				c.Get(id)
+// Len reports the number of distinct entries
+func TestCacheLen(t *testing.T) {
+	const n = 500
+
+	for _, impl := range implementations {
+		t.Run(impl.name, func(t *testing.T) {
+			c := impl.new().(interface {
+				Cache
+				Len() int
+			})
+			for i := 0; i < n; i++ {
+				id := fmt.Sprintf("disk-%d", i)
+				c.Update(id, &DiskStatus{ID: id})
 			}
-			i++
+			// Overwrites must not inflate the count
+			c.Update("disk-0", &DiskStatus{ID: "disk-0", Temp: 50})
+			if got := c.Len(); got != n {
+				t.Errorf("expected Len %d, got %d", n, got)
+			}
+		})
+	}
+
+	t.Run("HybridColdShadowed", func(t *testing.T) {
+		c := NewHybridCache()
+		c.UpdateCold("disk-1", &DiskStatus{ID: "disk-1"})
+		c.UpdateCold("disk-2", &DiskStatus{ID: "disk-2"})
+		c.Update("disk-1", &DiskStatus{ID: "disk-1"})
+		if got := c.Len(); got != 2 {
+			t.Errorf("expected Len 2, got %d", got)
 		}
 	})
 }
 
-func BenchmarkSyncMapMixed(b *testing.B) {
-	c := initSyncMapCache()
-	b.ResetTimer()
-	b.SetParallelism(benchParallel)
-	b.RunParallel(func(pb *testing.PB) {
-		i := 0
-		for pb.Next() {
-			id := fmt.Sprintf("disk-%d", i%numKeys)
-			if i%readRatio == 0 {
-				status := &DiskStatus{ID: id, Health: 100, Temp: 45}
-				c.Update(id, status)
-			} else {
-				c.Get(id)
+func TestShardedCacheWithShards(t *testing.T) {
+	for _, n := range []int{1, 4, 128} {
+		t.Run(fmt.Sprintf("shards=%d", n), func(t *testing.T) {
+			c := NewShardedCacheWithShards(n)
+			if len(c.base.table.Load().shards) != n {
+				t.Fatalf("expected %d shards, got %d", n, len(c.base.table.Load().shards))
+			}
+			for i := 0; i < numKeys; i++ {
+				id := fmt.Sprintf("disk-%d", i)
+				c.Update(id, &DiskStatus{ID: id})
+			}
+			for i := 0; i < numKeys; i++ {
+				id := fmt.Sprintf("disk-%d", i)
+				if got := c.Get(id); got == nil || got.ID != id {
+					t.Fatalf("expected %s, got %v", id, got)
+				}
+			}
+		})
+	}
+
+	t.Run("fallback", func(t *testing.T) {
+		for _, n := range []int{0, -1} {
+			if got := len(NewShardedCacheWithShards(n).base.table.Load().shards); got != ShardCount {
+				t.Errorf("n=%d: expected %d shards, got %d", n, ShardCount, got)
 			}
-			i++
 		}
 	})
 }
 
-func BenchmarkSpinLockMixed(b *testing.B) {
-	c := initSpinLockCache()
-	b.ResetTimer()
-	b.SetParallelism(benchParallel)
-	b.RunParallel(func(pb *testing.PB) {
-		i := 0
-		for pb.Next() {
-			id := fmt.Sprintf("disk-%d", i%numKeys)
-			if i%readRatio == 0 {
-				status := &DiskStatus{ID: id, Health: 100, Temp: 45}
-				c.Update(id, status)
-			} else {
-				c.Get(id)
+func TestShardedCacheResizeShards(t *testing.T) {
+	t.Run("Rehash", func(t *testing.T) {
+		c := NewShardedCacheWithShards(4)
+		initCache(c)
+		c.ResizeShards(16)
+
+		if got := len(c.ShardSizes()); got != 16 {
+			t.Fatalf("expected 16 shards, got %d", got)
+		}
+		for i := 0; i < numKeys; i++ {
+			id := fmt.Sprintf("disk-%d", i)
+			if got := c.Get(id); got == nil || got.ID != id {
+				t.Fatalf("expected %s after resize, got %v", id, got)
 			}
-			i++
+			if !slices.Contains(c.ShardKeys(c.ShardOf(id)), id) {
+				t.Errorf("%s not stored in its new shard %d", id, c.ShardOf(id))
+			}
+		}
+		if got := c.Len(); got != numKeys {
+			t.Errorf("expected Len %d, got %d", numKeys, got)
 		}
 	})
-}
 
-func BenchmarkCOWMixed(b *testing.B) {
-	c := initCOWCache()
-	b.ResetTimer()
-	b.SetParallelism(benchParallel)
-	b.RunParallel(func(pb *testing.PB) {
-		i := 0
-		for pb.Next() {
-			id := fmt.Sprintf("disk-%d", i%numKeys)
-			if i%readRatio == 0 {
-				status := &DiskStatus{ID: id, Health: 100, Temp: 45}
-				c.Update(id, status)
-			} else {
-				c.Get(id)
-			}
-			i++
+	t.Run("ConcurrentWrites", func(t *testing.T) {
+		const writers = 8
+		c := NewShardedCacheWithShards(2)
+		var wg sync.WaitGroup
+		for w := 0; w < writers; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				for i := 0; i < numKeys/writers; i++ {
+					id := fmt.Sprintf("disk-%d-%d", w, i)
+					c.Update(id, &DiskStatus{ID: id})
+				}
+			}(w)
+		}
+		for _, n := range []int{4, 8, 16, 32} {
+			c.ResizeShards(n)
+		}
+		wg.Wait()
+
+		// No write may be lost in a retired table
+		if got := c.Len(); got != writers*(numKeys/writers) {
+			t.Errorf("expected Len %d, got %d", writers*(numKeys/writers), got)
 		}
 	})
 }
 
-func BenchmarkHybridMixed(b *testing.B) {
-	c := initHybridCache()
-	b.ResetTimer()
-	b.SetParallelism(benchParallel)
-	b.RunParallel(func(pb *testing.PB) {
-		i := 0
-		for pb.Next() {
-			id := fmt.Sprintf("disk-%d", i%numKeys)
-			if i%readRatio == 0 {
-				status := &DiskStatus{ID: id, Health: 100, Temp: 45}
-				c.Update(id, status)
-			} else {
-				c.Get(id)
-			}
-			i++
+func TestGetOrComputeOnce(t *testing.T) {
+	const goroutines = 100
+
+	caches := []struct {
+		name string
+		c    interface {
+			GetOrCompute(id string, compute func() *DiskStatus) *DiskStatus
 		}
-	})
+	}{
+		{"MutexCache", NewMutexCache()},
+		{"RWMutexCache", NewRWMutexCache()},
+		{"ShardedCache", NewShardedCache()},
+	}
+
+	for _, tc := range caches {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls int32
+			compute := func() *DiskStatus {
+				atomic.AddInt32(&calls, 1)
+				return &DiskStatus{ID: "disk-1", Health: 100}
+			}
+
+			var wg sync.WaitGroup
+			wg.Add(goroutines)
+			results := make([]*DiskStatus, goroutines)
+			for i := 0; i < goroutines; i++ {
+				go func(i int) {
+					defer wg.Done()
+					results[i] = tc.c.GetOrCompute("disk-1", compute)
+				}(i)
+			}
+			wg.Wait()
+
+			if calls != 1 {
+				t.Errorf("expected compute to run once, ran %d times", calls)
+			}
+			for i, got := range results {
+				if got != results[0] {
+					t.Errorf("goroutine %d got a different value: %v", i, got)
+				}
+			}
+		})
+	}
 }
 
-// Basic correctness tests
-func TestCacheCorrectness(t *testing.T) {
+// fakeClock is a manually advanced clock for TTL tests
+type fakeClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{t: time.Unix(1700000000, 0)}
+}
+
+func (f *fakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.t
+}
+
+func (f *fakeClock) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.t = f.t.Add(d)
+}
+
+func TestMutexCacheTTL(t *testing.T) {
+	clock := newFakeClock()
+	c := NewMutexCache()
+	c.now = clock.Now
 	status := &DiskStatus{ID: "disk-1", Health: 100, Temp: 45}
 
-	t.Run("MutexCache", func(t *testing.T) {
-		c := NewMutexCache()
-		c.Update("disk-1", status)
-		got := c.Get("disk-1")
-		if got == nil || got.ID != "disk-1" {
-			t.Errorf("expected disk-1, got %v", got)
+	t.Run("Expires", func(t *testing.T) {
+		c.UpdateWithTTL("disk-1", status, time.Minute)
+		clock.Advance(59 * time.Second)
+		if got := c.Get("disk-1"); got != status {
+			t.Fatalf("expected disk-1 before TTL, got %v", got)
+		}
+		clock.Advance(time.Second)
+		if got := c.Get("disk-1"); got != nil {
+			t.Fatalf("expected nil after TTL, got %v", got)
+		}
+		// Expired entry was lazily removed
+		if got := c.Len(); got != 0 {
+			t.Errorf("expected Len 0 after lazy delete, got %d", got)
 		}
 	})
 
-	t.Run("RWMutexCache", func(t *testing.T) {
-		c := NewRWMutexCache()
-		c.Update("disk-1", status)
-		got := c.Get("disk-1")
-		if got == nil || got.ID != "disk-1" {
-			t.Errorf("expected disk-1, got %v", got)
+	t.Run("UpdateResets", func(t *testing.T) {
+		c.UpdateWithTTL("disk-1", status, time.Minute)
+		clock.Advance(50 * time.Second)
+		c.UpdateWithTTL("disk-1", status, time.Minute)
+		clock.Advance(50 * time.Second)
+		if got := c.Get("disk-1"); got != status {
+			t.Errorf("expected refreshed TTL to keep disk-1, got %v", got)
 		}
-	})
 
-	t.Run("ShardedCache", func(t *testing.T) {
-		c := NewShardedCache()
 		c.Update("disk-1", status)
-		got := c.Get("disk-1")
-		if got == nil || got.ID != "disk-1" {
-			t.Errorf("expected disk-1, got %v", got)
+		clock.Advance(time.Hour)
+		if got := c.Get("disk-1"); got != status {
+			t.Errorf("expected plain Update to clear TTL, got %v", got)
 		}
 	})
 
-	t.Run("SyncMapCache", func(t *testing.T) {
-		c := NewSyncMapCache()
-		c.Update("disk-1", status)
-		got := c.Get("disk-1")
-		if got == nil || got.ID != "disk-1" {
-			t.Errorf("expected disk-1, got %v", got)
+	t.Run("Touch", func(t *testing.T) {
+		c.UpdateWithTTL("disk-1", status, time.Minute)
+		clock.Advance(50 * time.Second)
+		if !c.Touch("disk-1", time.Minute) {
+			t.Fatalf("expected Touch to find disk-1")
+		}
+		clock.Advance(50 * time.Second) // past the original TTL
+		if got := c.Get("disk-1"); got != status {
+			t.Errorf("expected touched disk-1 to survive, got %v", got)
+		}
+
+		clock.Advance(time.Minute)
+		if c.Touch("disk-1", time.Minute) || c.Get("disk-1") != nil {
+			t.Errorf("expected Touch not to resurrect an expired entry")
 		}
 	})
 
-	t.Run("SpinLockCache", func(t *testing.T) {
-		c := NewSpinLockCache()
-		c.Update("disk-1", status)
-		got := c.Get("disk-1")
-		if got == nil || got.ID != "disk-1" {
-			t.Errorf("expected disk-1, got %v", got)
+	t.Run("GetStale", func(t *testing.T) {
+		c.UpdateWithTTL("disk-1", status, time.Minute)
+		if got, stale, ok := c.GetStale("disk-1"); !ok || stale || got != status {
+			t.Fatalf("expected fresh disk-1, got (%v, %v, %v)", got, stale, ok)
+		}
+		clock.Advance(time.Minute)
+		if got, stale, ok := c.GetStale("disk-1"); !ok || !stale || got != status {
+			t.Errorf("expected stale disk-1, got (%v, %v, %v)", got, stale, ok)
+		}
+		// Still there for the next GetStale until overwritten
+		if _, stale, ok := c.GetStale("disk-1"); !ok || !stale {
+			t.Errorf("expected GetStale not to remove the entry")
+		}
+		fresh := &DiskStatus{ID: "disk-1", Temp: 50}
+		c.Update("disk-1", fresh)
+		if got, stale, ok := c.GetStale("disk-1"); !ok || stale || got != fresh {
+			t.Errorf("expected the overwrite, got (%v, %v, %v)", got, stale, ok)
+		}
+		if got, stale, ok := c.GetStale("missing"); ok || stale || got != nil {
+			t.Errorf("expected miss, got (%v, %v, %v)", got, stale, ok)
 		}
 	})
 
-	t.Run("COWCache", func(t *testing.T) {
-		c := NewCOWCache()
+	t.Run("GetWithExpiry", func(t *testing.T) {
+		c.UpdateWithTTL("disk-1", status, time.Minute)
+		clock.Advance(20 * time.Second)
+		got, ttl, ok := c.GetWithExpiry("disk-1")
+		if !ok || got != status {
+			t.Fatalf("expected disk-1, got (%v, %v)", got, ok)
+		}
+		if ttl < 39*time.Second || ttl > 40*time.Second {
+			t.Errorf("expected about 40s left, got %v", ttl)
+		}
+
 		c.Update("disk-1", status)
-		got := c.Get("disk-1")
-		if got == nil || got.ID != "disk-1" {
-			t.Errorf("expected disk-1, got %v", got)
+		if _, ttl, ok := c.GetWithExpiry("disk-1"); !ok || ttl != NoExpiry {
+			t.Errorf("expected NoExpiry without a TTL, got (%v, %v)", ttl, ok)
+		}
+
+		c.UpdateWithTTL("disk-1", status, time.Second)
+		clock.Advance(time.Second)
+		if got, ttl, ok := c.GetWithExpiry("disk-1"); ok || got != nil || ttl != 0 {
+			t.Errorf("expected expired miss, got (%v, %v, %v)", got, ttl, ok)
 		}
 	})
 
-	t.Run("HybridCache", func(t *testing.T) {
-		c := NewHybridCache()
-		c.Update("disk-1", status)
-		got := c.Get("disk-1")
-		if got == nil || got.ID != "disk-1" {
-			t.Errorf("expected disk-1, got %v", got)
+	t.Run("RealClock", func(t *testing.T) {
+		c := NewMutexCache()
+		c.UpdateWithTTL("disk-1", status, 10*time.Millisecond)
+		time.Sleep(20 * time.Millisecond)
+		if got := c.Get("disk-1"); got != nil {
+			t.Errorf("expected nil after TTL, got %v", got)
 		}
 	})
 }
 
-// Concurrent correctness test
-func TestCacheConcurrency(t *testing.T) {
-	const goroutines = 100
-	const operations = 1000
+func TestMutexCacheGetVersioned(t *testing.T) {
+	c := NewMutexCache()
+	if got, v, ok := c.GetVersioned("disk-1"); ok || got != nil || v != 0 {
+		t.Fatalf("expected miss, got (%v, %d, %v)", got, v, ok)
+	}
 
-	t.Run("ShardedCache", func(t *testing.T) {
-		c := NewShardedCache()
-		var wg sync.WaitGroup
-		wg.Add(goroutines)
+	c.Update("disk-1", &DiskStatus{ID: "disk-1", Temp: 30})
+	_, v1, ok := c.GetVersioned("disk-1")
+	if !ok {
+		t.Fatalf("expected disk-1 to be present")
+	}
+	// Re-reading doesn't bump the version
+	if _, v, _ := c.GetVersioned("disk-1"); v != v1 {
+		t.Errorf("expected version %d on re-read, got %d", v1, v)
+	}
 
-		for i := 0; i < goroutines; i++ {
-			go func(id int) {
-				defer wg.Done()
-				for j := 0; j < operations; j++ {
-					key := fmt.Sprintf("disk-%d", j%100)
-					status := &DiskStatus{ID: key, Health: 100, Temp: 45}
-					c.Update(key, status)
-					c.Get(key)
-				}
-			}(i)
-		}
+	c.Update("disk-1", &DiskStatus{ID: "disk-1", Temp: 31})
+	got, v2, _ := c.GetVersioned("disk-1")
+	if got.Temp != 31 || v2 <= v1 {
+		t.Errorf("expected Temp 31 with version above %d, got %d at version %d", v1, got.Temp, v2)
+	}
+
+	// Other write paths count too
+	c.IncrementTemp("disk-1", 1)
+	if _, v3, _ := c.GetVersioned("disk-1"); v3 <= v2 {
+		t.Errorf("expected version above %d after IncrementTemp, got %d", v2, v3)
+	}
+}
+
+func TestMutexCacheGetMeta(t *testing.T) {
+	clock := newFakeClock()
+	c := NewMutexCache()
+	c.now = clock.Now
+	if _, _, _, ok := c.GetMeta("disk-1"); ok {
+		t.Fatalf("expected miss")
+	}
+
+	start := clock.Now()
+	c.Update("disk-1", &DiskStatus{ID: "disk-1", Temp: 30})
+	clock.Advance(time.Minute)
+	c.Update("disk-1", &DiskStatus{ID: "disk-1", Temp: 31})
+
+	got, created, updated, ok := c.GetMeta("disk-1")
+	if !ok || got.Temp != 31 {
+		t.Fatalf("expected Temp 31, got (%v, %v)", got, ok)
+	}
+	if !created.Equal(start) {
+		t.Errorf("expected CreatedAt %v, got %v", start, created)
+	}
+	if want := start.Add(time.Minute); !updated.Equal(want) {
+		t.Errorf("expected UpdatedAt %v, got %v", want, updated)
+	}
+
+	// A key stored again after Delete starts over
+	c.Delete("disk-1")
+	clock.Advance(time.Minute)
+	c.Update("disk-1", &DiskStatus{ID: "disk-1"})
+	if _, created, _, _ := c.GetMeta("disk-1"); !created.Equal(clock.Now()) {
+		t.Errorf("expected CreatedAt reset to %v, got %v", clock.Now(), created)
+	}
+}
+
+func TestMutexCacheChangedSince(t *testing.T) {
+	c := NewMutexCache()
+	for i := 0; i < 10; i++ {
+		id := fmt.Sprintf("disk-%d", i)
+		c.Update(id, &DiskStatus{ID: id})
+	}
+	all, gen := c.ChangedSince(0)
+	if len(all) != 10 {
+		t.Fatalf("expected all 10 entries since generation 0, got %d", len(all))
+	}
+
+	want := map[string]bool{"disk-2": true, "disk-5": true, "disk-7": true}
+	for id := range want {
+		c.Update(id, &DiskStatus{ID: id, Temp: 50})
+	}
+	changed, next := c.ChangedSince(gen)
+	got := make(map[string]bool, len(changed))
+	for _, status := range changed {
+		got[status.ID] = true
+	}
+	if !maps.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if next <= gen {
+		t.Errorf("expected generation to advance past %d, got %d", gen, next)
+	}
+
+	if changed, again := c.ChangedSince(next); len(changed) != 0 || again != next {
+		t.Errorf("expected no changes at generation %d, got %d entries at %d", next, len(changed), again)
+	}
+}
+
+func TestMutexCacheJanitor(t *testing.T) {
+	c := NewMutexCache()
+	for i := 0; i < 10; i++ {
+		id := fmt.Sprintf("disk-%d", i)
+		c.UpdateWithTTL(id, &DiskStatus{ID: id}, 10*time.Millisecond)
+	}
+
+	stop := c.StartJanitor(5 * time.Millisecond)
+	defer stop()
+
+	deadline := time.Now().Add(time.Second)
+	for c.Len() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("janitor did not sweep expired entries, Len = %d", c.Len())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// Stopping twice must not panic or block
+	stop()
+	stop()
+}
+
+func TestCacheStats(t *testing.T) {
+	for _, impl := range implementations {
+		t.Run(impl.name, func(t *testing.T) {
+			c := impl.new().(interface {
+				Cache
+				Stats() Stats
+			})
+			for i := 0; i < 3; i++ {
+				id := fmt.Sprintf("disk-%d", i)
+				c.Update(id, &DiskStatus{ID: id})
+			}
+			// 5 hits on present keys, 4 misses on absent ones
+			for i := 0; i < 5; i++ {
+				c.Get(fmt.Sprintf("disk-%d", i%3))
+			}
+			for i := 0; i < 4; i++ {
+				c.Get(fmt.Sprintf("absent-%d", i))
+			}
+
+			want := Stats{Hits: 5, Misses: 4, Updates: 3}
+			got := c.Stats()
+			got.Copies, got.CopiedEntries = 0, 0 // COW only, see TestCOWCacheCopyStats
+			if got != want {
+				t.Errorf("expected %+v, got %+v", want, got)
+			}
+		})
+	}
+}
+
+func TestCOWCacheCopyStats(t *testing.T) {
+	const size, updates = 100, 10
+	c := NewCOWCache()
+	for i := 0; i < size; i++ {
+		id := fmt.Sprintf("disk-%d", i)
+		c.Update(id, &DiskStatus{ID: id})
+	}
+	// Filling copies 0 + 1 + ... + size-1 entries
+	if got, want := c.Stats().CopiedEntries, uint64(size*(size-1)/2); got != want {
+		t.Errorf("expected %d copied entries while filling, got %d", want, got)
+	}
+
+	before := c.Stats()
+	for i := 0; i < updates; i++ {
+		c.Update("disk-0", &DiskStatus{ID: "disk-0", Temp: i})
+	}
+	c.Delete("disk-1")
+	got := c.Stats()
+	if n := got.Copies - before.Copies; n != updates+1 {
+		t.Errorf("expected %d copies, got %d", updates+1, n)
+	}
+	// Each overwrite copies all size entries; the delete copies the rest
+	if n := got.CopiedEntries - before.CopiedEntries; n != updates*size+size-1 {
+		t.Errorf("expected %d copied entries, got %d", updates*size+size-1, n)
+	}
+}
+
+func TestCOWCacheGetAll(t *testing.T) {
+	for _, safe := range []bool{false, true} {
+		t.Run(fmt.Sprintf("safe=%v", safe), func(t *testing.T) {
+			c := NewCOWCache()
+			c.Update("disk-1", &DiskStatus{ID: "disk-1", Temp: 40})
+			c.Update("disk-2", &DiskStatus{ID: "disk-2"})
+
+			all := c.GetAll(safe)
+			c.Update("disk-1", &DiskStatus{ID: "disk-1", Temp: 90})
+			c.Update("disk-3", &DiskStatus{ID: "disk-3"})
+			c.Delete("disk-2")
+
+			if len(all) != 2 || all["disk-1"].Temp != 40 || all["disk-2"] == nil {
+				t.Errorf("expected the state at call time, got %v", all)
+			}
+		})
+	}
+
+	t.Run("UnsafeIsLiveMap", func(t *testing.T) {
+		c := NewCOWCache()
+		status := &DiskStatus{ID: "disk-1"}
+		c.Update("disk-1", status)
+		if got := c.GetAll(false)["disk-1"]; got != status {
+			t.Errorf("expected the stored pointer without copying, got %p want %p", got, status)
+		}
+	})
+}
+
+func TestCacheKeys(t *testing.T) {
+	want := []string{"disk-0", "disk-1", "disk-2", "disk-3", "disk-4"}
+
+	for _, impl := range implementations {
+		t.Run(impl.name, func(t *testing.T) {
+			c := impl.new().(interface {
+				Cache
+				Keys() []string
+			})
+			for _, id := range want {
+				c.Update(id, &DiskStatus{ID: id})
+			}
+			got := c.Keys()
+			slices.Sort(got)
+			if !slices.Equal(got, want) {
+				t.Errorf("expected %v, got %v", want, got)
+			}
+		})
+	}
+
+	t.Run("HybridBothTiers", func(t *testing.T) {
+		c := NewHybridCache()
+		c.UpdateCold("disk-0", &DiskStatus{ID: "disk-0"})
+		c.UpdateCold("disk-1", &DiskStatus{ID: "disk-1"})
+		c.Update("disk-1", &DiskStatus{ID: "disk-1"})
+		c.Update("disk-2", &DiskStatus{ID: "disk-2"})
+		got := c.Keys()
+		slices.Sort(got)
+		if want := []string{"disk-0", "disk-1", "disk-2"}; !slices.Equal(got, want) {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+	})
+}
+
+func TestCacheRange(t *testing.T) {
+	const n = 100
+
+	caches := []struct {
+		name string
+		c    interface {
+			Cache
+			Range(fn func(id string, status *DiskStatus) bool)
+		}
+	}{
+		{"MutexCache", NewMutexCache()},
+		{"RWMutexCache", NewRWMutexCache()},
+		{"ShardedCache", NewShardedCache()},
+		{"SyncMapCache", NewSyncMapCache()},
+	}
+
+	for _, tc := range caches {
+		t.Run(tc.name, func(t *testing.T) {
+			for i := 0; i < n; i++ {
+				id := fmt.Sprintf("disk-%d", i)
+				tc.c.Update(id, &DiskStatus{ID: id})
+			}
+
+			seen := make(map[string]bool)
+			tc.c.Range(func(id string, status *DiskStatus) bool {
+				if status.ID != id {
+					t.Errorf("entry %s has status for %s", id, status.ID)
+				}
+				seen[id] = true
+				return true
+			})
+			if len(seen) != n {
+				t.Errorf("expected to visit %d entries, visited %d", n, len(seen))
+			}
+
+			visited := 0
+			tc.c.Range(func(id string, status *DiskStatus) bool {
+				visited++
+				return visited < 10
+			})
+			if visited != 10 {
+				t.Errorf("expected Range to stop after 10 entries, visited %d", visited)
+			}
+		})
+	}
+}
+
+func TestCacheClear(t *testing.T) {
+	for _, impl := range implementations {
+		t.Run(impl.name, func(t *testing.T) {
+			c := initCache(impl.new()).(interface {
+				Cache
+				Len() int
+				Clear()
+			})
+			c.Clear()
+			if got := c.Len(); got != 0 {
+				t.Errorf("expected Len 0 after Clear, got %d", got)
+			}
+			if got := c.Get("disk-1"); got != nil {
+				t.Errorf("expected nil after Clear, got %v", got)
+			}
+
+			// The cache must stay usable afterwards
+			c.Update("disk-1", &DiskStatus{ID: "disk-1"})
+			if got := c.Get("disk-1"); got == nil {
+				t.Errorf("expected disk-1 after re-insert, got nil")
+			}
+		})
+	}
+
+	t.Run("HybridCold", func(t *testing.T) {
+		c := NewHybridCache()
+		c.UpdateCold("disk-1", &DiskStatus{ID: "disk-1"})
+		c.Clear()
+		if got := c.Get("disk-1"); got != nil {
+			t.Errorf("expected cold tier to be cleared, got %v", got)
+		}
+	})
+}
+
+type batchUpdater interface {
+	Cache
+	UpdateBatch(items map[string]*DiskStatus)
+}
+
+func batchItems() map[string]*DiskStatus {
+	items := make(map[string]*DiskStatus, numKeys)
+	for _, status := range prepareTestData() {
+		items[status.ID] = status
+	}
+	return items
+}
+
+func TestReplaceAll(t *testing.T) {
+	caches := []struct {
+		name string
+		c    interface {
+			Cache
+			Len() int
+			ReplaceAll(m map[string]*DiskStatus)
+		}
+	}{
+		{"MutexCache", NewMutexCache()},
+		{"RWMutexCache", NewRWMutexCache()},
+	}
+
+	for _, tc := range caches {
+		t.Run(tc.name, func(t *testing.T) {
+			c := tc.c
+			c.Update("old-1", &DiskStatus{ID: "old-1"})
+			c.Update("shared", &DiskStatus{ID: "shared", Temp: 40})
+
+			next := map[string]*DiskStatus{
+				"shared": {ID: "shared", Temp: 50},
+				"new-1":  {ID: "new-1"},
+			}
+			c.ReplaceAll(next)
+			// The cache took a copy, so later edits to next don't leak in
+			delete(next, "new-1")
+
+			if got := c.Get("old-1"); got != nil {
+				t.Errorf("expected old-1 gone, got %v", got)
+			}
+			if got := c.Get("shared"); got == nil || got.Temp != 50 {
+				t.Errorf("expected replaced shared, got %v", got)
+			}
+			if got := c.Get("new-1"); got == nil {
+				t.Errorf("expected new-1 present")
+			}
+			if got := c.Len(); got != 2 {
+				t.Errorf("expected Len 2, got %d", got)
+			}
+		})
+	}
+
+	t.Run("MutexCacheEvicts", func(t *testing.T) {
+		c := NewMutexCache()
+		rec := newEvictRecorder()
+		c.OnEvict(rec.record)
+		c.Update("old-1", &DiskStatus{ID: "old-1"})
+		c.Update("shared", &DiskStatus{ID: "shared"})
+		c.ReplaceAll(map[string]*DiskStatus{"shared": {ID: "shared"}})
+		rec.expect(t, map[string]int{"old-1": 1})
+	})
+}
+
+func TestUpdateBatch(t *testing.T) {
+	caches := []struct {
+		name string
+		c    batchUpdater
+	}{
+		{"MutexCache", NewMutexCache()},
+		{"RWMutexCache", NewRWMutexCache()},
+		{"ShardedCache", NewShardedCache()},
+	}
+
+	items := batchItems()
+	for _, tc := range caches {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.c.UpdateBatch(items)
+			for id, want := range items {
+				if got := tc.c.Get(id); got != want {
+					t.Fatalf("expected %v for %s, got %v", want, id, got)
+				}
+			}
+		})
+	}
+}
+
+// Benchmark: concurrent loaders applying numKeys entries one Update at a time
+// vs one UpdateBatch
+func BenchmarkUpdateBatch(b *testing.B) {
+	caches := []struct {
+		name string
+		new  func() batchUpdater
+	}{
+		{"Mutex", func() batchUpdater { return NewMutexCache() }},
+		{"RWMutex", func() batchUpdater { return NewRWMutexCache() }},
+		{"Sharded", func() batchUpdater { return NewShardedCache() }},
+	}
+
+	items := batchItems()
+	for _, tc := range caches {
+		b.Run(tc.name+"/Loop", func(b *testing.B) {
+			c := tc.new()
+			b.SetParallelism(benchParallel)
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					for id, status := range items {
+						c.Update(id, status)
+					}
+				}
+			})
+		})
+		b.Run(tc.name+"/Batch", func(b *testing.B) {
+			c := tc.new()
+			b.SetParallelism(benchParallel)
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					c.UpdateBatch(items)
+				}
+			})
+		})
+	}
+}
+
+func TestGetBatch(t *testing.T) {
+	caches := []struct {
+		name string
+		c    interface {
+			Cache
+			GetBatch(ids []string) map[string]*DiskStatus
+		}
+	}{
+		{"MutexCache", NewMutexCache()},
+		{"RWMutexCache", NewRWMutexCache()},
+		{"ShardedCache", NewShardedCache()},
+	}
+
+	for _, tc := range caches {
+		t.Run(tc.name, func(t *testing.T) {
+			initCache(tc.c)
+			ids := []string{"disk-1", "absent-1", "disk-500", "absent-2", "disk-999"}
+			got := tc.c.GetBatch(ids)
+			if len(got) != 3 {
+				t.Errorf("expected 3 results, got %d: %v", len(got), got)
+			}
+			for _, id := range []string{"disk-1", "disk-500", "disk-999"} {
+				if status, ok := got[id]; !ok || status.ID != id {
+					t.Errorf("expected %s in result, got %v", id, status)
+				}
+			}
+			for _, id := range []string{"absent-1", "absent-2"} {
+				if _, ok := got[id]; ok {
+					t.Errorf("expected %s to be absent from result", id)
+				}
+			}
+		})
+	}
+}
+
+func TestShardedCacheGetMultiParallel(t *testing.T) {
+	c := NewShardedCache()
+	initCache(c)
+	ids := make([]string, 0, numKeys+10)
+	for i := 0; i < numKeys+10; i++ {
+		ids = append(ids, fmt.Sprintf("disk-%d", i))
+	}
+
+	got := c.GetMultiParallel(ids)
+	if len(got) != numKeys {
+		t.Fatalf("expected %d results, got %d", numKeys, len(got))
+	}
+	if want := c.GetBatch(ids); !maps.Equal(got, want) {
+		t.Errorf("expected the same result as GetBatch")
+	}
+	for _, id := range ids[numKeys:] {
+		if _, ok := got[id]; ok {
+			t.Errorf("expected %s to be absent from result", id)
+		}
+	}
+}
+
+// Benchmark: reading every key at once, one shard at a time vs all shards
+// concurrently
+func BenchmarkGetMulti(b *testing.B) {
+	c := NewShardedCache()
+	initCache(c)
+	ids := make([]string, numKeys)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("disk-%d", i)
+	}
+	b.Run("GetBatch", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			c.GetBatch(ids)
+		}
+	})
+	b.Run("GetMultiParallel", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			c.GetMultiParallel(ids)
+		}
+	})
+}
+
+func TestRWMutexCacheGetMany(t *testing.T) {
+	c := NewRWMutexCache()
+	c.Update("disk-1", &DiskStatus{ID: "disk-1"})
+	c.Update("disk-3", &DiskStatus{ID: "disk-3"})
+
+	ids := []string{"disk-3", "missing", "disk-1", "disk-1", "disk-2"}
+	got := c.GetMany(ids)
+	if len(got) != len(ids) {
+		t.Fatalf("expected %d results, got %d", len(ids), len(got))
+	}
+	for i, id := range ids {
+		hit := id == "disk-1" || id == "disk-3"
+		if (got[i] != nil) != hit || (hit && got[i].ID != id) {
+			t.Errorf("position %d (%s): expected hit %v, got %v", i, id, hit, got[i])
+		}
+	}
+	if s := c.Stats(); s.Hits != 3 || s.Misses != 2 {
+		t.Errorf("expected 3 hits and 2 misses, got %+v", s)
+	}
+}
+
+func TestFNV32a(t *testing.T) {
+	for _, id := range []string{"", "disk-1", "rack-07/disk-42"} {
+		h := fnv.New32a()
+		h.Write([]byte(id))
+		if got, want := fnv32a(id), h.Sum32(); got != want {
+			t.Errorf("fnv32a(%q) = %d, want %d", id, got, want)
+		}
+	}
+}
+
+func TestShardDistribution(t *testing.T) {
+	const keys = 100000
+	c := NewShardedCache()
+	counts := make([]int, len(c.base.table.Load().shards))
+	for i := 0; i < keys; i++ {
+		counts[c.base.getShard(fmt.Sprintf("disk-%d", i))]++
+	}
+
+	// Every shard should be within 10% of the mean
+	mean := keys / len(counts)
+	for i, n := range counts {
+		if n < mean*9/10 || n > mean*11/10 {
+			t.Errorf("shard %d has %d keys, mean is %d", i, n, mean)
+		}
+	}
+}
+
+// Benchmark: shard selection with hash/fnv vs the inlined FNV-1a loop
+func BenchmarkGetShard(b *testing.B) {
+	ids := make([]string, numKeys)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("disk-%d", i)
+	}
+
+	b.Run("HashFNV", func(b *testing.B) {
+		b.ReportAllocs()
+		var sink int
+		for i := 0; i < b.N; i++ {
+			h := fnv.New32a()
+			h.Write([]byte(ids[i%numKeys]))
+			sink += int(h.Sum32() % ShardCount)
+		}
+		_ = sink
+	})
+
+	b.Run("Inline", func(b *testing.B) {
+		b.ReportAllocs()
+		c := NewShardedCache()
+		var sink int
+		for i := 0; i < b.N; i++ {
+			sink += c.base.getShard(ids[i%numKeys])
+		}
+		_ = sink
+	})
+}
+
+func TestCacheContains(t *testing.T) {
+	type containsCache interface {
+		Cache
+		Contains(id string) bool
+		Delete(id string)
+	}
+
+	caches := []struct {
+		name string
+		new  func() Cache
+	}{
+		{"LRU", func() Cache { return NewLRUCache(10) }},
+		{"LFU", func() Cache { return NewLFUCache(10) }},
+	}
+	for _, impl := range append(caches, implementations...) {
+		t.Run(impl.name, func(t *testing.T) {
+			c := impl.new().(containsCache)
+			c.Update("disk-1", &DiskStatus{ID: "disk-1"})
+			if !c.Contains("disk-1") {
+				t.Errorf("expected Contains(disk-1) to be true")
+			}
+			if c.Contains("disk-2") {
+				t.Errorf("expected Contains(disk-2) to be false")
+			}
+			c.Delete("disk-1")
+			if c.Contains("disk-1") {
+				t.Errorf("expected Contains(disk-1) to be false after Delete")
+			}
+		})
+	}
+
+	t.Run("HybridCold", func(t *testing.T) {
+		c := NewHybridCache()
+		c.UpdateCold("disk-1", &DiskStatus{ID: "disk-1"})
+		if !c.Contains("disk-1") {
+			t.Errorf("expected cold disk-1 to be contained")
+		}
+	})
+}
+
+// agreeCache is the surface TestAllImplementationsAgree drives
+type agreeCache interface {
+	Cache
+	Delete(id string)
+	Len() int
+	Contains(id string) bool
+}
+
+// agreeChecks compare a cache against the reference map after every step.
+// Add an entry here to cover a new method across all implementations.
+var agreeChecks = []struct {
+	name  string
+	check func(c agreeCache, ref map[string]*DiskStatus, id string) (got, want any)
+}{
+	{"Get", func(c agreeCache, ref map[string]*DiskStatus, id string) (any, any) {
+		// Compare values, since some caches hand out copies
+		deref := func(s *DiskStatus) any {
+			if s == nil {
+				return nil
+			}
+			return *s
+		}
+		return deref(c.Get(id)), deref(ref[id])
+	}},
+	{"Contains", func(c agreeCache, ref map[string]*DiskStatus, id string) (any, any) {
+		_, ok := ref[id]
+		return c.Contains(id), ok
+	}},
+	{"Len", func(c agreeCache, ref map[string]*DiskStatus, id string) (any, any) {
+		return c.Len(), len(ref)
+	}},
+}
+
+// Every implementation must behave like a plain map under the same random
+// sequence of Update, Delete and Get.
+func TestAllImplementationsAgree(t *testing.T) {
+	const (
+		steps = 2000
+		keys  = 50
+	)
+
+	caches := []struct {
+		name string
+		new  func() Cache
+	}{
+		{"LRU", func() Cache { return NewLRUCache(0) }},
+		{"LFU", func() Cache { return NewLFUCache(0) }},
+		{"KeyLock", func() Cache { return NewKeyLockCache() }},
+		{"RCU", func() Cache { return NewRCUCache() }},
+	}
+	for _, impl := range append(caches, implementations...) {
+		t.Run(impl.name, func(t *testing.T) {
+			c := impl.new().(agreeCache)
+			ref := make(map[string]*DiskStatus)
+			// Same seed for every implementation, so they all see one sequence
+			r := rand.New(rand.NewSource(1))
+
+			for step := 0; step < steps; step++ {
+				id := fmt.Sprintf("disk-%d", r.Intn(keys))
+				op := "Get"
+				switch n := r.Intn(10); {
+				case n < 4:
+					op = "Update"
+					status := &DiskStatus{ID: id, Temp: step}
+					c.Update(id, status)
+					ref[id] = status
+				case n < 6:
+					op = "Delete"
+					c.Delete(id)
+					delete(ref, id)
+				case n < 7:
+					op = "UpdateNil"
+					c.Update(id, nil)
+					delete(ref, id)
+				}
+				for _, ac := range agreeChecks {
+					if got, want := ac.check(c, ref, id); got != want {
+						t.Fatalf("step %d (%s %s): %s expected %v, got %v", step, op, id, ac.name, want, got)
+					}
+				}
+			}
+		})
+	}
+}
+
+// Update with a nil status deletes, so nil can't be stored and mistaken for
+// a miss
+func TestUpdateNilDeletes(t *testing.T) {
+	caches := []struct {
+		name string
+		new  func() Cache
+	}{
+		{"LRU", func() Cache { return NewLRUCache(10) }},
+		{"LFU", func() Cache { return NewLFUCache(10) }},
+		{"Clock", func() Cache { return NewClockCache(10) }},
+		{"BoundedCOW", func() Cache { return NewBoundedCOWCache(10) }},
+		{"BufferedCOW", func() Cache { return NewBufferedCOWCache(DefaultMergeAfter) }},
+		{"HealthPriority", func() Cache { return NewHealthPriorityCache(10) }},
+		{"Policy", func() Cache { return NewPolicyCache(10, NewLRUPolicy()) }},
+		{"ShardedLRU", func() Cache { return NewShardedLRUCache(4, 10) }},
+		{"KeyLock", func() Cache { return NewKeyLockCache() }},
+		{"RCU", func() Cache { return NewRCUCache() }},
+	}
+	for _, impl := range append(caches, implementations...) {
+		t.Run(impl.name, func(t *testing.T) {
+			c := impl.new().(interface {
+				Cache
+				Contains(id string) bool
+			})
+			c.Update("disk-1", &DiskStatus{ID: "disk-1"})
+			c.Update("disk-1", nil)
+			if got := c.Get("disk-1"); got != nil {
+				t.Errorf("expected nil after Update(nil), got %v", got)
+			}
+			if c.Contains("disk-1") {
+				t.Errorf("expected Contains false after Update(nil)")
+			}
+			// Deleting a missing key is a no-op, not a stored nil
+			c.Update("disk-2", nil)
+			if c.Contains("disk-2") {
+				t.Errorf("expected Update(nil) of a missing key to store nothing")
+			}
+		})
+	}
+
+	t.Run("Tiered", func(t *testing.T) {
+		l1, l2 := NewLRUCache(10), NewMutexCache()
+		c := NewTieredCache(l1, l2)
+		c.Update("disk-1", &DiskStatus{ID: "disk-1"})
+		c.Update("disk-1", nil)
+		if c.Get("disk-1") != nil || l1.Contains("disk-1") || l2.Contains("disk-1") {
+			t.Errorf("expected Update(nil) to delete from both tiers")
+		}
+	})
+}
+
+// Every write path an implementation has treats nil the way Update does. write
+// reports false if c lacks the method.
+func TestNilWritesDeleteEveryImplementation(t *testing.T) {
+	paths := []struct {
+		name  string
+		id    string // the key that must end up absent
+		write func(c Cache) bool
+	}{
+		{"UpdateBatch", "disk-1", func(c Cache) bool {
+			b, ok := c.(interface{ UpdateBatch(map[string]*DiskStatus) })
+			if ok {
+				b.UpdateBatch(map[string]*DiskStatus{"disk-1": nil})
+			}
+			return ok
+		}},
+		{"ReplaceAll", "disk-1", func(c Cache) bool {
+			r, ok := c.(interface{ ReplaceAll(map[string]*DiskStatus) })
+			if ok {
+				r.ReplaceAll(map[string]*DiskStatus{"disk-1": nil})
+			}
+			return ok
+		}},
+		{"GetOrCompute", "disk-2", func(c Cache) bool {
+			g, ok := c.(interface {
+				GetOrCompute(string, func() *DiskStatus) *DiskStatus
+			})
+			if ok {
+				g.GetOrCompute("disk-2", func() *DiskStatus { return nil })
+			}
+			return ok
+		}},
+		{"Swap", "disk-1", func(c Cache) bool {
+			s, ok := c.(interface {
+				Swap(string, *DiskStatus) *DiskStatus
+			})
+			if ok {
+				s.Swap("disk-1", nil)
+			}
+			return ok
+		}},
+		{"CompareAndUpdate", "disk-1", func(c Cache) bool {
+			s, ok := c.(interface {
+				CompareAndUpdate(string, *DiskStatus, *DiskStatus) bool
+			})
+			if ok {
+				s.CompareAndUpdate("disk-1", c.Get("disk-1"), nil)
+			}
+			return ok
+		}},
+		{"UpdateIfAbsent", "disk-2", func(c Cache) bool {
+			u, ok := c.(interface {
+				UpdateIfAbsent(string, *DiskStatus) bool
+			})
+			if ok {
+				u.UpdateIfAbsent("disk-2", nil)
+			}
+			return ok
+		}},
+		{"UpdateCold", "disk-1", func(c Cache) bool {
+			h, ok := c.(*HybridCache)
+			if ok {
+				h.UpdateCold("disk-1", nil)
+			}
+			return ok
+		}},
+		{"BulkLoadCold", "disk-1", func(c Cache) bool {
+			h, ok := c.(*HybridCache)
+			if ok {
+				h.BulkLoadCold(map[string]*DiskStatus{"disk-1": nil})
+			}
+			return ok
+		}},
+	}
+	for _, impl := range implementations {
+		for _, p := range paths {
+			t.Run(impl.name+"/"+p.name, func(t *testing.T) {
+				c := impl.new().(interface {
+					Cache
+					Contains(id string) bool
+					Len() int
+				})
+				c.Update("disk-1", &DiskStatus{ID: "disk-1"})
+				if !p.write(c) {
+					t.Skipf("%s has no %s", impl.name, p.name)
+				}
+				if c.Get(p.id) != nil || c.Contains(p.id) {
+					t.Errorf("expected %s absent after a nil write", p.id)
+				}
+				want := 0
+				if p.id != "disk-1" {
+					want = 1
+				}
+				if got := c.Len(); got != want {
+					t.Errorf("expected Len %d, got %d", want, got)
+				}
+			})
+		}
+	}
+}
+
+// Every other write path treats nil the way Update does
+func TestNilWritesDelete(t *testing.T) {
+	type nilWrite struct {
+		name  string
+		id    string // the key that must end up absent
+		write func(c writeCache)
+	}
+	nilCompute := func() *DiskStatus { return nil }
+	common := []nilWrite{
+		{"CompareAndUpdate", "disk-1", func(c writeCache) { c.CompareAndUpdate("disk-1", c.Get("disk-1"), nil) }},
+		{"Swap", "disk-1", func(c writeCache) { c.Swap("disk-1", nil) }},
+		{"UpdateBatch", "disk-1", func(c writeCache) { c.UpdateBatch(map[string]*DiskStatus{"disk-1": nil}) }},
+		{"UnmarshalJSON", "disk-1", func(c writeCache) {
+			c.(json.Unmarshaler).UnmarshalJSON([]byte(`{"disk-1": null}`))
+		}},
+		{"UpdateIfAbsent", "disk-2", func(c writeCache) { c.UpdateIfAbsent("disk-2", nil) }},
+		{"GetOrCompute", "disk-2", func(c writeCache) { c.GetOrCompute("disk-2", nilCompute) }},
+	}
+	caches := []struct {
+		name  string
+		new   func() writeCache
+		extra []nilWrite
+	}{
+		{"MutexCache", func() writeCache { return NewMutexCache() }, []nilWrite{
+			{"UpdateWithTTL", "disk-1", func(c writeCache) { c.(*MutexCache).UpdateWithTTL("disk-1", nil, time.Minute) }},
+			{"ReplaceAll", "disk-1", func(c writeCache) {
+				c.(*MutexCache).ReplaceAll(map[string]*DiskStatus{"disk-1": nil})
+			}},
+		}},
+		{"ShardedCache", func() writeCache { return NewShardedCache() }, nil},
+	}
+	for _, impl := range caches {
+		for _, w := range append(common, impl.extra...) {
+			t.Run(impl.name+"/"+w.name, func(t *testing.T) {
+				c := impl.new()
+				c.Update("disk-1", &DiskStatus{ID: "disk-1"})
+				w.write(c)
+				if c.Get(w.id) != nil || c.Contains(w.id) {
+					t.Errorf("expected %s absent after a nil write", w.id)
+				}
+				if want := len(c.Snapshot()); c.Len() != want {
+					t.Errorf("expected Len %d, got %d", want, c.Len())
+				}
+			})
+		}
+	}
+
+	t.Run("SyncMapCache", func(t *testing.T) {
+		c := NewSyncMapCache()
+		c.Update("disk-1", &DiskStatus{ID: "disk-1"})
+		if old := c.Swap("disk-1", nil); old == nil || c.Contains("disk-1") {
+			t.Errorf("expected Swap(nil) to return the old value and delete, got %v", old)
+		}
+		if !c.UpdateIfAbsent("disk-2", nil) || c.Contains("disk-2") || c.Len() != 0 {
+			t.Errorf("expected UpdateIfAbsent(nil) to store nothing")
+		}
+	})
+
+	t.Run("HybridCache", func(t *testing.T) {
+		c := NewHybridCache()
+		c.UpdateCold("disk-1", &DiskStatus{ID: "disk-1"})
+		c.Get("disk-1")
+		c.UpdateCold("disk-1", nil)
+		if c.Get("disk-1") != nil || c.Len() != 0 {
+			t.Errorf("expected UpdateCold(nil) to delete from both tiers")
+		}
+	})
+}
+
+func TestCacheGetCopy(t *testing.T) {
+	for _, impl := range implementations {
+		t.Run(impl.name, func(t *testing.T) {
+			c := impl.new().(interface {
+				Cache
+				GetCopy(id string) *DiskStatus
+			})
+			c.Update("disk-1", &DiskStatus{ID: "disk-1", Health: 100, Temp: 45})
+
+			cp := c.GetCopy("disk-1")
+			if cp == nil || cp.ID != "disk-1" {
+				t.Fatalf("expected copy of disk-1, got %v", cp)
+			}
+			cp.Health = 0
+			cp.Temp = 99
+
+			if got := c.Get("disk-1"); got.Health != 100 || got.Temp != 45 {
+				t.Errorf("mutating the copy changed the cached value: %v", got)
+			}
+			if got := c.GetCopy("absent"); got != nil {
+				t.Errorf("expected nil for absent key, got %v", got)
+			}
+		})
+	}
+}
+
+func TestCompareAndUpdate(t *testing.T) {
+	caches := []struct {
+		name string
+		c    interface {
+			Cache
+			CompareAndUpdate(id string, old, new *DiskStatus) bool
+		}
+	}{
+		{"MutexCache", NewMutexCache()},
+		{"ShardedCache", NewShardedCache()},
+	}
+
+	for _, tc := range caches {
+		t.Run(tc.name, func(t *testing.T) {
+			c := tc.c
+			v1 := &DiskStatus{ID: "disk-1", Temp: 40}
+			if !c.CompareAndUpdate("disk-1", nil, v1) {
+				t.Fatalf("expected insert with old=nil to succeed")
+			}
+
+			stale := c.Get("disk-1")
+			v2 := &DiskStatus{ID: "disk-1", Temp: 50}
+			if !c.CompareAndUpdate("disk-1", stale, v2) {
+				t.Fatalf("expected swap from current value to succeed")
+			}
+
+			// A second writer still holding the stale pointer must lose
+			v3 := &DiskStatus{ID: "disk-1", Temp: 60}
+			if c.CompareAndUpdate("disk-1", stale, v3) {
+				t.Errorf("expected swap from stale value to be rejected")
+			}
+			if got := c.Get("disk-1"); got != v2 {
+				t.Errorf("expected cache to retain newer value %v, got %v", v2, got)
+			}
+		})
+	}
+}
+
+func TestUpdateIfAbsent(t *testing.T) {
+	const goroutines = 50
+
+	caches := []struct {
+		name string
+		c    interface {
+			Cache
+			UpdateIfAbsent(id string, status *DiskStatus) bool
+		}
+	}{
+		{"MutexCache", NewMutexCache()},
+		{"ShardedCache", NewShardedCache()},
+		{"SyncMapCache", NewSyncMapCache()},
+	}
+
+	for _, tc := range caches {
+		t.Run(tc.name, func(t *testing.T) {
+			c := tc.c
+			var stored atomic.Int32
+			var winner atomic.Pointer[DiskStatus]
+			var wg sync.WaitGroup
+			for g := 0; g < goroutines; g++ {
+				wg.Add(1)
+				go func(g int) {
+					defer wg.Done()
+					status := &DiskStatus{ID: "disk-1", Temp: g}
+					if c.UpdateIfAbsent("disk-1", status) {
+						stored.Add(1)
+						winner.Store(status)
+					}
+				}(g)
+			}
+			wg.Wait()
+
+			if n := stored.Load(); n != 1 {
+				t.Fatalf("expected exactly one initializer, got %d", n)
+			}
+			if got := c.Get("disk-1"); got != winner.Load() {
+				t.Errorf("expected the winner's value, got %v", got)
+			}
+			if c.UpdateIfAbsent("disk-1", &DiskStatus{ID: "disk-1"}) {
+				t.Errorf("expected an existing key not to be overwritten")
+			}
+		})
+	}
+}
+
+func TestSwap(t *testing.T) {
+	caches := []struct {
+		name string
+		c    interface {
+			Cache
+			Swap(id string, status *DiskStatus) *DiskStatus
+		}
+	}{
+		{"MutexCache", NewMutexCache()},
+		{"ShardedCache", NewShardedCache()},
+		{"SyncMapCache", NewSyncMapCache()},
+	}
+
+	for _, tc := range caches {
+		t.Run(tc.name, func(t *testing.T) {
+			c := tc.c
+			first := &DiskStatus{ID: "disk-1", Temp: 30}
+			if old := c.Swap("disk-1", first); old != nil {
+				t.Errorf("expected nil for a missing key, got %v", old)
+			}
+			second := &DiskStatus{ID: "disk-1", Temp: 31}
+			if old := c.Swap("disk-1", second); old != first {
+				t.Errorf("expected %v, got %v", first, old)
+			}
+			if got := c.Get("disk-1"); got != second {
+				t.Errorf("expected %v stored, got %v", second, got)
+			}
+		})
+	}
+}
+
+func TestShardedCacheUpdateFields(t *testing.T) {
+	stored := func(c *ShardedCache, id string) *DiskStatus {
+		return c.base.table.Load().shards[c.ShardOf(id)].data[id]
+	}
+
+	t.Run("Stores", func(t *testing.T) {
+		c := NewRecyclingShardedCache(0)
+		c.UpdateFields("disk-1", 90, 40)
+		c.UpdateFields("disk-1", 80, 41)
+		if got := c.Get("disk-1"); got == nil || *got != (DiskStatus{ID: "disk-1", Health: 80, Temp: 41}) {
+			t.Errorf("expected {disk-1 80 41}, got %v", got)
+		}
+	})
+
+	t.Run("ReadersKeepTheirValue", func(t *testing.T) {
+		c := NewRecyclingShardedCache(0)
+		c.UpdateFields("disk-1", 90, 40)
+		held := c.Get("disk-1")
+		for i := 0; i < 100; i++ {
+			c.UpdateFields("disk-1", i, i)
+		}
+		if *held != (DiskStatus{ID: "disk-1", Health: 90, Temp: 40}) {
+			t.Errorf("expected the value a reader holds to stay unchanged, got %v", held)
+		}
+	})
+
+	t.Run("Recycles", func(t *testing.T) {
+		c := NewRecyclingShardedCache(0)
+		seen := make(map[*DiskStatus]bool)
+		for i := 0; i < 100; i++ {
+			c.UpdateFields("disk-1", i, i)
+			seen[stored(c, "disk-1")] = true
+		}
+		// sync.Pool may drop some structs, but not every one
+		if len(seen) == 100 {
+			t.Errorf("expected unread structs to be reused, got 100 distinct")
+		}
+	})
+
+	t.Run("CallerStructsNotRecycled", func(t *testing.T) {
+		c := NewRecyclingShardedCache(0)
+		mine := &DiskStatus{ID: "disk-1", Health: 1}
+		c.UpdateFields("disk-1", 90, 40)
+		c.Update("disk-1", mine)
+		c.UpdateFields("disk-1", 80, 41)
+		c.UpdateFields("disk-1", 70, 42)
+		if mine.Health != 1 {
+			t.Errorf("expected a struct stored by Update to stay untouched, got %v", mine)
+		}
+	})
+
+	t.Run("OffByDefault", func(t *testing.T) {
+		c := NewShardedCache()
+		seen := make(map[*DiskStatus]bool)
+		for i := 0; i < 100; i++ {
+			c.UpdateFields("disk-1", i, i)
+			c.Get("disk-1")
+			seen[stored(c, "disk-1")] = true
+		}
+		if len(seen) != 100 {
+			t.Errorf("expected a new struct per call, got %d distinct", len(seen))
+		}
+		if locks := c.base.table.Load().shards[c.ShardOf("disk-1")].mu.locks.Load(); locks != 0 {
+			t.Errorf("expected no lock counting, got %d", locks)
+		}
+	})
+
+	t.Run("RecordsDroppedWithTheKey", func(t *testing.T) {
+		writes := map[string]func(c *ShardedCache){
+			"Update":       func(c *ShardedCache) { c.Update("disk-1", &DiskStatus{ID: "disk-1"}) },
+			"Delete":       func(c *ShardedCache) { c.Delete("disk-1") },
+			"GetAndDelete": func(c *ShardedCache) { c.GetAndDelete("disk-1") },
+			"Swap":         func(c *ShardedCache) { c.Swap("disk-1", nil) },
+			"Rename":       func(c *ShardedCache) { c.Rename("disk-1", "disk-2") },
+			"Clear":        func(c *ShardedCache) { c.Clear() },
+			"WithShard":    func(c *ShardedCache) { c.WithShard("disk-1", func(map[string]*DiskStatus) {}) },
+			"ResizeShards": func(c *ShardedCache) { c.ResizeShards(8) },
+		}
+		for name, write := range writes {
+			c := NewRecyclingShardedCache(0)
+			c.UpdateFields("disk-1", 90, 40)
+			write(c)
+			c.pooled.Range(func(id, p any) bool {
+				t.Errorf("%s: expected no pooled records left, got %s: %v", name, id, p)
+				return true
+			})
+		}
+	})
+}
+
+// Benchmark: overwriting entries with no reads in between. Update allocates a
+// struct per call; UpdateFields recycles the one it displaces.
+func BenchmarkUpdateFields(b *testing.B) {
+	ids := make([]string, numKeys)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("disk-%d", i)
+	}
+	b.Run("Update", func(b *testing.B) {
+		c := initCache(NewShardedCache())
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			id := ids[i%numKeys]
+			c.Update(id, &DiskStatus{ID: id, Health: 100, Temp: i})
+		}
+	})
+	b.Run("UpdateFields", func(b *testing.B) {
+		c := NewRecyclingShardedCache(0)
+		for _, id := range ids {
+			c.UpdateFields(id, 100, 45)
+		}
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			c.UpdateFields(ids[i%numKeys], 100, i)
+		}
+	})
+}
+
+func TestShardedCacheRename(t *testing.T) {
+	c := NewShardedCache()
+	// Find a pair of ids sharing a shard and one in another shard
+	same, other := "", ""
+	for i := 1; same == "" || other == ""; i++ {
+		id := fmt.Sprintf("disk-%d", i)
+		if c.ShardOf(id) == c.ShardOf("disk-0") {
+			if same == "" {
+				same = id
+			}
+		} else if other == "" {
+			other = id
+		}
+	}
 
-		wg.Wait()
+	tests := []struct {
+		name, newID string
+	}{
+		{"WithinShard", same},
+		{"AcrossShards", other},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			status := &DiskStatus{ID: "disk-0", Temp: 40}
+			c.Update("disk-0", status)
+			if !c.Rename("disk-0", tc.newID) {
+				t.Fatalf("expected disk-0 to exist")
+			}
+			if c.Contains("disk-0") {
+				t.Errorf("expected disk-0 to be gone")
+			}
+			if got := c.Get(tc.newID); got != status {
+				t.Errorf("expected %v under %s, got %v", status, tc.newID, got)
+			}
+			c.Delete(tc.newID)
+		})
+	}
+
+	if c.Rename("missing", "disk-9") || c.Contains("disk-9") {
+		t.Errorf("expected renaming a missing key to do nothing")
+	}
+}
+
+func TestGetAndDelete(t *testing.T) {
+	const goroutines = 100
+
+	caches := []struct {
+		name string
+		c    interface {
+			Cache
+			GetAndDelete(id string) *DiskStatus
+		}
+	}{
+		{"MutexCache", NewMutexCache()},
+		{"RWMutexCache", NewRWMutexCache()},
+		{"ShardedCache", NewShardedCache()},
+		{"SyncMapCache", NewSyncMapCache()},
+	}
+
+	for _, tc := range caches {
+		t.Run(tc.name, func(t *testing.T) {
+			status := &DiskStatus{ID: "disk-1"}
+			tc.c.Update("disk-1", status)
+
+			var claimed int32
+			var wg sync.WaitGroup
+			wg.Add(goroutines)
+			for i := 0; i < goroutines; i++ {
+				go func() {
+					defer wg.Done()
+					if got := tc.c.GetAndDelete("disk-1"); got != nil {
+						if got != status {
+							t.Errorf("claimed unexpected value %v", got)
+						}
+						atomic.AddInt32(&claimed, 1)
+					}
+				}()
+			}
+			wg.Wait()
+
+			if claimed != 1 {
+				t.Errorf("expected exactly one claim, got %d", claimed)
+			}
+			if got := tc.c.Get("disk-1"); got != nil {
+				t.Errorf("expected disk-1 to be gone, got %v", got)
+			}
+		})
+	}
+}
+
+func TestRecommendedShardCount(t *testing.T) {
+	tests := []struct {
+		keys, goroutines, want int
+	}{
+		{1000, 8, 32},         // 4 per goroutine
+		{1000, 100, 64},       // capped at 1000/16 = 62, rounded up
+		{100000, 64, 256},     // 4 per goroutine
+		{10, 8, 1},            // too few keys to be worth sharding
+		{1000000, 1000, 1024}, // hard cap
+		{0, 8, 32},            // unknown key count
+		{0, 3, 16},            // 12 rounded up
+	}
+	for _, tt := range tests {
+		got := RecommendedShardCount(tt.keys, tt.goroutines)
+		if got != tt.want {
+			t.Errorf("RecommendedShardCount(%d, %d) = %d, want %d", tt.keys, tt.goroutines, got, tt.want)
+		}
+	}
+
+	if got := RecommendedShardCount(0, 0); got&(got-1) != 0 || got < 1 {
+		t.Errorf("expected a power of two for GOMAXPROCS goroutines, got %d", got)
+	}
+	if got := len(NewAutoShardedCache(1000, 8).ShardSizes()); got != 32 {
+		t.Errorf("expected NewAutoShardedCache to use 32 shards, got %d", got)
+	}
+}
+
+func TestSeededShardedCache(t *testing.T) {
+	const keys = 64
+	ids := make([]string, keys)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("disk-%d", i)
+	}
+	routing := func(c *ShardedCache) []int {
+		shards := make([]int, keys)
+		for i, id := range ids {
+			shards[i] = c.ShardOf(id)
+		}
+		return shards
+	}
+
+	c := NewSeededShardedCache(ShardCount)
+	first := routing(c)
+	if again := routing(c); !slices.Equal(first, again) {
+		t.Fatalf("expected stable routing within an instance")
+	}
+	for _, id := range ids {
+		c.Update(id, &DiskStatus{ID: id})
+	}
+	for _, id := range ids {
+		if got := c.Get(id); got == nil || got.ID != id {
+			t.Errorf("expected %s, got %v", id, got)
+		}
+	}
+
+	// Each instance draws its own seed; 64 keys over 32 shards all landing in
+	// the same place for several fresh seeds would mean the seed is ignored
+	differs := false
+	for i := 0; i < 5 && !differs; i++ {
+		differs = !slices.Equal(first, routing(NewSeededShardedCache(ShardCount)))
+	}
+	if !differs {
+		t.Errorf("expected instances with different seeds to route differently")
+	}
+}
+
+func TestWeightedShardedCache(t *testing.T) {
+	// Route by rack prefix: "rack-N/..." goes to shard N
+	byRack := func(id string) int {
+		var rack int
+		fmt.Sscanf(id, "rack-%d/", &rack)
+		return rack
+	}
+	c := NewWeightedShardedCache(4, byRack)
+
+	ids := []string{"rack-0/disk-1", "rack-1/disk-1", "rack-1/disk-2", "rack-3/disk-1", "rack-5/disk-1", "rack--2/disk-1"}
+	for _, id := range ids {
+		c.Update(id, &DiskStatus{ID: id})
+	}
+
+	want := [][]string{
+		{"rack-0/disk-1"},
+		{"rack-1/disk-1", "rack-1/disk-2", "rack-5/disk-1"}, // 5 mod 4
+		{"rack--2/disk-1"}, // -2 wraps to 2
+		{"rack-3/disk-1"},
+	}
+	for i, w := range want {
+		got := c.ShardKeys(i)
+		slices.Sort(got)
+		if !slices.Equal(got, w) {
+			t.Errorf("shard %d: expected %v, got %v", i, w, got)
+		}
+	}
+	for _, id := range ids {
+		if got := c.Get(id); got == nil || got.ID != id {
+			t.Errorf("expected %s to be retrievable, got %v", id, got)
+		}
+	}
+}
+
+// With every key hashed onto shard 0 the cache is one big mutex map: slower,
+// but it must still behave the same.
+func TestShardedCacheHashCollisions(t *testing.T) {
+	const goroutines, perGoroutine = 8, 100
+	c := newShardedCacheWithHash(ShardCount, func(string) uint32 { return 0 })
+
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < perGoroutine; i++ {
+				id := fmt.Sprintf("disk-%d-%d", g, i)
+				c.Update(id, &DiskStatus{ID: id, Temp: i})
+				if got := c.Get(id); got == nil || got.Temp != i {
+					t.Errorf("expected %s with Temp %d, got %v", id, i, got)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	sizes := c.ShardSizes()
+	if sizes[0] != goroutines*perGoroutine || c.Len() != goroutines*perGoroutine {
+		t.Fatalf("expected all %d keys on shard 0, got sizes %v", goroutines*perGoroutine, sizes)
+	}
+	for i, n := range sizes[1:] {
+		if n != 0 {
+			t.Errorf("expected shard %d empty, got %d", i+1, n)
+		}
+	}
+
+	// Two-key operations must cope with both keys on one shard
+	if !c.Rename("disk-0-0", "renamed") || c.Get("renamed") == nil || c.Contains("disk-0-0") {
+		t.Errorf("expected Rename within shard 0 to move the entry")
+	}
+	c.Delete("renamed")
+	if c.Contains("renamed") || c.Len() != goroutines*perGoroutine-1 {
+		t.Errorf("expected Delete to remove one entry, Len %d", c.Len())
+	}
+}
+
+func TestCacheSnapshot(t *testing.T) {
+	caches := []struct {
+		name string
+		new  func() Cache
+	}{
+		{"LRU", func() Cache { return NewLRUCache(10) }},
+		{"LFU", func() Cache { return NewLFUCache(10) }},
+	}
+	for _, impl := range append(caches, implementations...) {
+		t.Run(impl.name, func(t *testing.T) {
+			c := impl.new().(interface {
+				Cache
+				Snapshot() map[string]*DiskStatus
+			})
+			c.Update("disk-1", &DiskStatus{ID: "disk-1", Temp: 40})
+			c.Update("disk-2", &DiskStatus{ID: "disk-2", Temp: 41})
+
+			snap := c.Snapshot()
+			c.Update("disk-1", &DiskStatus{ID: "disk-1", Temp: 99})
+			c.Update("disk-3", &DiskStatus{ID: "disk-3"})
+			c.Get("disk-2").Temp = 99 // mutate a live value in place
+
+			if len(snap) != 2 {
+				t.Errorf("expected 2 entries in snapshot, got %d", len(snap))
+			}
+			if got := snap["disk-1"]; got == nil || got.Temp != 40 {
+				t.Errorf("expected snapshot disk-1 Temp 40, got %v", got)
+			}
+			if got := snap["disk-2"]; got == nil || got.Temp != 41 {
+				t.Errorf("expected snapshot disk-2 Temp 41, got %v", got)
+			}
+		})
+	}
+
+	t.Run("HybridBothTiers", func(t *testing.T) {
+		c := NewHybridCache()
+		c.UpdateCold("disk-1", &DiskStatus{ID: "disk-1", Temp: 30})
+		c.UpdateCold("disk-2", &DiskStatus{ID: "disk-2", Temp: 30})
+		c.Update("disk-1", &DiskStatus{ID: "disk-1", Temp: 40})
+		snap := c.Snapshot()
+		if len(snap) != 2 || snap["disk-1"].Temp != 40 || snap["disk-2"].Temp != 30 {
+			t.Errorf("expected hot entries to shadow cold ones, got %v", snap)
+		}
+	})
+}
+
+func TestSpinLockGetCtx(t *testing.T) {
+	c := NewSpinLockCache()
+	c.Update("disk-1", &DiskStatus{ID: "disk-1"})
+
+	t.Run("Uncontended", func(t *testing.T) {
+		got, err := c.GetCtx(context.Background(), "disk-1")
+		if err != nil || got == nil {
+			t.Errorf("expected disk-1, got (%v, %v)", got, err)
+		}
+	})
+
+	t.Run("CancelledWhileSpinning", func(t *testing.T) {
+		c.base.acquire() // simulate another goroutine holding the lock
+		defer c.base.release()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(10*time.Millisecond, cancel)
+
+		done := make(chan error, 1)
+		go func() {
+			_, err := c.GetCtx(ctx, "disk-1")
+			done <- err
+		}()
+
+		select {
+		case err := <-done:
+			if err != context.Canceled {
+				t.Errorf("expected context.Canceled, got %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("GetCtx did not return after cancellation")
+		}
+	})
+}
+
+func TestShardSizes(t *testing.T) {
+	c := NewShardedCacheWithShards(8)
+
+	// Pick 50 keys that all hash to the same shard as disk-0
+	hot := c.ShardOf("disk-0")
+	var colliding []string
+	for i := 0; len(colliding) < 50; i++ {
+		id := fmt.Sprintf("disk-%d", i)
+		if c.ShardOf(id) == hot {
+			colliding = append(colliding, id)
+		}
+	}
+	for _, id := range colliding {
+		c.Update(id, &DiskStatus{ID: id})
+	}
+
+	sizes := c.ShardSizes()
+	if len(sizes) != 8 {
+		t.Fatalf("expected 8 shard sizes, got %d", len(sizes))
+	}
+	for i, n := range sizes {
+		want := 0
+		if i == hot {
+			want = 50
+		}
+		if n != want {
+			t.Errorf("shard %d: expected %d entries, got %d", i, want, n)
+		}
+	}
+}
+
+func TestUpdateFunc(t *testing.T) {
+	const goroutines = 100
+	const increments = 100
+
+	caches := []struct {
+		name string
+		c    interface {
+			Cache
+			UpdateFunc(id string, fn func(*DiskStatus) *DiskStatus)
+		}
+	}{
+		{"MutexCache", NewMutexCache()},
+		{"ShardedCache", NewShardedCache()},
+	}
+
+	incrTemp := func(old *DiskStatus) *DiskStatus {
+		if old == nil {
+			return &DiskStatus{ID: "disk-1", Temp: 1}
+		}
+		next := *old
+		next.Temp++
+		return &next
+	}
+
+	for _, tc := range caches {
+		t.Run(tc.name, func(t *testing.T) {
+			var wg sync.WaitGroup
+			wg.Add(goroutines)
+			for i := 0; i < goroutines; i++ {
+				go func() {
+					defer wg.Done()
+					for j := 0; j < increments; j++ {
+						tc.c.UpdateFunc("disk-1", incrTemp)
+					}
+				}()
+			}
+			wg.Wait()
+
+			if got := tc.c.Get("disk-1"); got == nil || got.Temp != goroutines*increments {
+				t.Errorf("expected Temp %d, got %v (lost updates)", goroutines*increments, got)
+			}
+
+			tc.c.UpdateFunc("disk-1", func(*DiskStatus) *DiskStatus { return nil })
+			if got := tc.c.Get("disk-1"); got != nil {
+				t.Errorf("expected returning nil to delete, got %v", got)
+			}
+		})
+	}
+}
+
+func TestIncrementTemp(t *testing.T) {
+	const goroutines, increments = 20, 100
+
+	caches := []struct {
+		name string
+		c    interface {
+			Cache
+			IncrementTemp(id string, delta int) int
+			IncrementHealth(id string, delta int) int
+		}
+	}{
+		{"MutexCache", NewMutexCache()},
+		{"ShardedCache", NewShardedCache()},
+	}
+
+	for _, tc := range caches {
+		t.Run(tc.name, func(t *testing.T) {
+			c := tc.c
+			orig := &DiskStatus{ID: "disk-1", Health: 100, Temp: 40}
+			c.Update("disk-1", orig)
+
+			var wg sync.WaitGroup
+			for g := 0; g < goroutines; g++ {
+				wg.Add(1)
+				go func(delta int) {
+					defer wg.Done()
+					for i := 0; i < increments; i++ {
+						c.IncrementTemp("disk-1", delta)
+					}
+				}(g%3 - 1) // deltas -1, 0, 1
+			}
+			wg.Wait()
+
+			want := 40
+			for g := 0; g < goroutines; g++ {
+				want += (g%3 - 1) * increments
+			}
+			if got := c.Get("disk-1"); got.Temp != want || got.Health != 100 {
+				t.Errorf("expected Temp %d Health 100, got %+v", want, got)
+			}
+			if orig.Temp != 40 {
+				t.Errorf("expected the original struct untouched, got Temp %d", orig.Temp)
+			}
+
+			if got := c.IncrementHealth("disk-2", -5); got != -5 {
+				t.Errorf("expected a missing id to start from zero, got %d", got)
+			}
+		})
+	}
+}
+
+func TestSpinLockBackoff(t *testing.T) {
+	const goroutines, updates = 16, 200
+	c := NewSpinLockCacheWithBackoff(4, 100*time.Microsecond)
+
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < updates; i++ {
+				id := fmt.Sprintf("disk-%d-%d", g, i)
+				c.Update(id, &DiskStatus{ID: id})
+				if got := c.Get(id); got == nil || got.ID != id {
+					t.Errorf("expected %s, got %v", id, got)
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	if got := c.Len(); got != goroutines*updates {
+		t.Errorf("expected Len %d, got %d", goroutines*updates, got)
+	}
+
+	// Once the spins run out, sleeps double up to the cap
+	var b spinBackoff
+	var sleeps []time.Duration
+	for i := 0; i < 4+10; i++ {
+		c.base.wait(&b)
+		if b.sleep > 0 {
+			sleeps = append(sleeps, b.sleep)
+		}
+	}
+	if len(sleeps) != 10 || sleeps[0] != time.Microsecond || sleeps[1] != 2*time.Microsecond || sleeps[9] != c.base.maxSleep {
+		t.Errorf("expected sleeps from 1µs capped at %v, got %v", c.base.maxSleep, sleeps)
+	}
+}
+
+// Benchmark: mixed workload with far more goroutines than CPUs, where pure
+// spinning wastes the scheduler's time on Gosched loops
+func BenchmarkSpinLockBackoff(b *testing.B) {
+	caches := []struct {
+		name string
+		new  func() Cache
+	}{
+		{"Spin", func() Cache { return NewSpinLockCache() }},
+		{"Backoff", func() Cache { return NewSpinLockCacheWithBackoff(16, 50*time.Microsecond) }},
+	}
+	for _, impl := range caches {
+		b.Run(impl.name, func(b *testing.B) {
+			c := initCache(impl.new())
+			b.ResetTimer()
+			b.SetParallelism(4 * benchParallel)
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					id := fmt.Sprintf("disk-%d", i%numKeys)
+					if i%readRatio == 0 {
+						c.Update(id, &DiskStatus{ID: id, Health: 100, Temp: 45})
+					} else {
+						c.Get(id)
+					}
+					i++
+				}
+			})
+		})
+	}
+}
+
+func TestSpinLockTryGet(t *testing.T) {
+	c := NewSpinLockCache()
+	c.Update("disk-1", &DiskStatus{ID: "disk-1"})
+
+	if got, ok := c.TryGet("disk-1"); !ok || got == nil {
+		t.Errorf("expected uncontended TryGet to read disk-1, got (%v, %v)", got, ok)
+	}
+
+	// Hold the lock from another goroutine; TryGet must fail without blocking
+	locked := make(chan struct{})
+	release := make(chan struct{})
+	go func() {
+		c.base.acquire()
+		close(locked)
+		<-release
+		c.base.release()
+	}()
+	<-locked
+
+	done := make(chan bool, 1)
+	go func() {
+		_, ok := c.TryGet("disk-1")
+		done <- ok
+	}()
+	select {
+	case ok := <-done:
+		if ok {
+			t.Errorf("expected TryGet to fail while the lock is held")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("TryGet blocked on a held lock")
+	}
+	close(release)
+}
+
+func TestHybridCachePromotion(t *testing.T) {
+	inHot := func(c *HybridCache, id string) bool {
+		shard := &c.base.hot[c.base.getShard(id)]
+		shard.mu.RLock()
+		defer shard.mu.RUnlock()
+		_, ok := shard.data[id]
+		return ok
+	}
+
+	t.Run("PromotedAfterN", func(t *testing.T) {
+		c := NewHybridCacheWithPromotion(3)
+		c.UpdateCold("disk-1", &DiskStatus{ID: "disk-1", Temp: 30})
+		for i := 0; i < 2; i++ {
+			c.Get("disk-1")
+			if inHot(c, "disk-1") {
+				t.Fatalf("promoted after only %d cold reads", i+1)
+			}
+		}
+		c.Get("disk-1")
+		if !inHot(c, "disk-1") {
+			t.Fatalf("expected disk-1 in the hot tier after 3 cold reads")
+		}
+
+		// Cold writes still show through the promoted copy
+		c.UpdateCold("disk-1", &DiskStatus{ID: "disk-1", Temp: 35})
+		if got := c.Get("disk-1"); got.Temp != 35 {
+			t.Errorf("expected refreshed Temp 35, got %d", got.Temp)
+		}
+	})
+
+	t.Run("HotWriteNotClobbered", func(t *testing.T) {
+		c := NewHybridCacheWithPromotion(1)
+		c.UpdateCold("disk-1", &DiskStatus{ID: "disk-1", Temp: 30})
+		c.Update("disk-1", &DiskStatus{ID: "disk-1", Temp: 50})
+		c.UpdateCold("disk-1", &DiskStatus{ID: "disk-1", Temp: 31})
+		if got := c.Get("disk-1"); got.Temp != 50 {
+			t.Errorf("expected hot Temp 50 to shadow cold, got %d", got.Temp)
+		}
+	})
+
+	// A Get that read the cold tier before a write promotes only if its value
+	// is still current
+	t.Run("StalePromotionSkipped", func(t *testing.T) {
+		c := NewHybridCacheWithPromotion(1)
+		stale := &DiskStatus{ID: "disk-1", Temp: 30}
+		c.UpdateCold("disk-1", stale)
+		c.UpdateCold("disk-1", &DiskStatus{ID: "disk-1", Temp: 35})
+		c.maybePromote("disk-1", stale)
+		if got := c.Get("disk-1"); got.Temp != 35 {
+			t.Errorf("expected Temp 35, got %d", got.Temp)
+		}
+
+		c.Delete("disk-1")
+		c.maybePromote("disk-1", stale)
+		if inHot(c, "disk-1") || c.Get("disk-1") != nil {
+			t.Errorf("expected a deleted entry not to be promoted back")
+		}
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		c := NewHybridCacheWithPromotion(0)
+		c.UpdateCold("disk-1", &DiskStatus{ID: "disk-1"})
+		for i := 0; i < 10; i++ {
+			c.Get("disk-1")
+		}
+		if inHot(c, "disk-1") {
+			t.Errorf("expected no promotion when disabled")
+		}
+	})
+}
+
+func TestHybridCacheDemotion(t *testing.T) {
+	inHot := func(c *HybridCache, id string) bool {
+		shard := &c.base.hot[c.base.getShard(id)]
+		shard.mu.RLock()
+		defer shard.mu.RUnlock()
+		_, ok := shard.data[id]
+		return ok
+	}
+	inCold := func(c *HybridCache, id string) bool {
+		_, ok := c.base.loadCold()[id]
+		return ok
+	}
+
+	t.Run("Demote", func(t *testing.T) {
+		c := NewHybridCache()
+		c.Update("disk-1", &DiskStatus{ID: "disk-1", Temp: 40})
+		c.Demote("disk-1")
+		if inHot(c, "disk-1") || !inCold(c, "disk-1") {
+			t.Fatalf("expected disk-1 only in the cold tier")
+		}
+		if got := c.Get("disk-1"); got == nil || got.Temp != 40 {
+			t.Errorf("expected demoted disk-1 via the cold path, got %v", got)
+		}
+		c.Demote("missing")
+		if c.Contains("missing") {
+			t.Errorf("expected demoting a missing key to be a no-op")
+		}
+	})
+
+	t.Run("ShardOverflow", func(t *testing.T) {
+		const limit = 4
+		c := NewHybridCacheWithLimits(0, limit)
+
+		// Collect ids that all land in the same hot shard
+		var ids []string
+		for i := 0; len(ids) < limit+2; i++ {
+			id := fmt.Sprintf("disk-%d", i)
+			if c.base.getShard(id) == 0 {
+				ids = append(ids, id)
+			}
+		}
+		for _, id := range ids {
+			c.Update(id, &DiskStatus{ID: id})
+		}
+
+		for i, id := range ids {
+			overflow := i < len(ids)-limit
+			if inHot(c, id) == overflow || inCold(c, id) != overflow {
+				t.Errorf("%s: expected overflow %v, got hot %v cold %v", id, overflow, inHot(c, id), inCold(c, id))
+			}
+			if got := c.Get(id); got == nil || got.ID != id {
+				t.Errorf("expected %s to stay readable, got %v", id, got)
+			}
+		}
+		if got := c.Len(); got != len(ids) {
+			t.Errorf("expected Len %d, got %d", len(ids), got)
+		}
+	})
+}
+
+func TestHybridCacheBulkLoadCold(t *testing.T) {
+	c := NewHybridCacheWithPromotion(0)
+	c.UpdateCold("disk-0", &DiskStatus{ID: "disk-0", Temp: 10})
+	c.UpdateCold("extra", &DiskStatus{ID: "extra"})
+
+	items := batchItems()
+	c.BulkLoadCold(items)
+
+	cold := c.base.loadCold()
+	for id, want := range items {
+		if cold[id] != want {
+			t.Fatalf("expected %s in the cold tier, got %v", id, cold[id])
+		}
+		if got := c.Get(id); got != want {
+			t.Fatalf("expected %v for %s via the cold path, got %v", want, id, got)
+		}
+	}
+	if got := c.Get("extra"); got == nil {
+		t.Errorf("expected existing cold entries to be kept")
+	}
+	if got := c.Len(); got != len(items)+1 {
+		t.Errorf("expected Len %d, got %d", len(items)+1, got)
+	}
+	if got := c.Stats().Updates; got != uint64(len(items)+2) {
+		t.Errorf("expected %d updates, got %d", len(items)+2, got)
+	}
+}
+
+// Benchmark: warming the cold tier with numKeys entries one UpdateCold at a
+// time vs one BulkLoadCold
+func BenchmarkHybridColdLoad(b *testing.B) {
+	items := batchItems()
+	b.Run("Loop", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			c := NewHybridCache()
+			for id, status := range items {
+				c.UpdateCold(id, status)
+			}
+		}
+	})
+	b.Run("Bulk", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			NewHybridCache().BulkLoadCold(items)
+		}
+	})
+}
+
+func TestShardedCacheTempStats(t *testing.T) {
+	c := NewShardedCache()
+	if min, max, avg, count := c.TempStats(); min != 0 || max != 0 || avg != 0 || count != 0 {
+		t.Errorf("expected zeros for an empty cache, got (%d, %d, %v, %d)", min, max, avg, count)
+	}
+
+	temps := []int{42, -5, 60, 38, 45}
+	for i, temp := range temps {
+		id := fmt.Sprintf("disk-%d", i)
+		c.Update(id, &DiskStatus{ID: id, Temp: temp})
+	}
+	min, max, avg, count := c.TempStats()
+	if min != -5 || max != 60 || avg != 36 || count != 5 {
+		t.Errorf("expected (-5, 60, 36, 5), got (%d, %d, %v, %d)", min, max, avg, count)
+	}
+}
+
+func TestForEachShardParallel(t *testing.T) {
+	c := initCache(NewShardedCache()).(*ShardedCache)
+
+	// Each goroutine writes only its own slot, so no further locking is needed
+	counts := make([]int, len(c.ShardSizes()))
+	c.ForEachShardParallel(func(shard int, m map[string]*DiskStatus) {
+		counts[shard] = len(m)
+	})
+
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+	if want := c.Len(); total != want {
+		t.Errorf("expected parallel count %d to match Len, got %d", want, total)
+	}
+	if !slices.Equal(counts, c.ShardSizes()) {
+		t.Errorf("expected per-shard counts %v, got %v", c.ShardSizes(), counts)
+	}
+}
+
+func TestShardedCacheWithShard(t *testing.T) {
+	c := NewShardedCache()
+
+	// Find two ids that share a shard
+	a := "disk-0"
+	var b string
+	for i := 1; b == ""; i++ {
+		if id := fmt.Sprintf("disk-%d", i); c.ShardOf(id) == c.ShardOf(a) {
+			b = id
+		}
+	}
+	c.Update(a, &DiskStatus{ID: a, Temp: 30})
+	c.Update(b, &DiskStatus{ID: b, Temp: 60})
+
+	c.WithShard(a, func(m map[string]*DiskStatus) {
+		m[a], m[b] = m[b], m[a]
+	})
+
+	if got := c.Get(a); got == nil || got.Temp != 60 {
+		t.Errorf("expected %s to hold Temp 60, got %v", a, got)
+	}
+	if got := c.Get(b); got == nil || got.Temp != 30 {
+		t.Errorf("expected %s to hold Temp 30, got %v", b, got)
+	}
+}
+
+// FuzzShardedCache decodes the input as (op, key) byte pairs and applies them
+// to a ShardedCache and a plain reference map in lockstep. The ops run on one
+// goroutine so any failing corpus entry replays deterministically.
+func FuzzShardedCache(f *testing.F) {
+	f.Add([]byte{0, 1, 1, 1, 0, 1, 2, 1, 0, 1})
+	f.Add([]byte{1, 0, 1, 8, 1, 16, 2, 8, 0, 0, 0, 16})
+
+	f.Fuzz(func(t *testing.T, ops []byte) {
+		const keySpace = 8
+		c := NewShardedCacheWithShards(4)
+		ref := make(map[string]int)
+
+		for i := 0; i+1 < len(ops); i += 2 {
+			op, arg := ops[i]%3, int(ops[i+1])
+			id := fmt.Sprintf("disk-%d", arg%keySpace)
+			switch op {
+			case 0:
+				got := c.Get(id)
+				want, ok := ref[id]
+				if (got != nil) != ok || (ok && got.Temp != want) {
+					t.Fatalf("op %d: Get(%s) = %v, reference has (%d, %v)", i/2, id, got, want, ok)
+				}
+			case 1:
+				c.Update(id, &DiskStatus{ID: id, Temp: arg})
+				ref[id] = arg
+			case 2:
+				c.Delete(id)
+				delete(ref, id)
+			}
+		}
+		if got := c.Len(); got != len(ref) {
+			t.Fatalf("expected Len %d, got %d", len(ref), got)
+		}
 	})
 }
@@ -0,0 +1,62 @@
+package cache
+
+import "sync"
+
+// ChainCache generalizes TieredCache to any number of members, fastest first
+// (e.g. local, then remote-backed). Get returns the first hit and copies it
+// into every member before the one that had it.
+//
+// As in TieredCache, a backfill and an Update of the same id take the same
+// striped lock, so a Get can't copy a value into the earlier members after an
+// Update has replaced it.
+type ChainCache struct {
+	Members []Cache
+
+	locks [ShardCount]sync.Mutex
+}
+
+var _ Cache = (*ChainCache)(nil)
+
+func NewChainCache(members ...Cache) *ChainCache {
+	return &ChainCache{Members: members}
+}
+
+func (c *ChainCache) lock(id string) *sync.Mutex {
+	return &c.locks[fnv32a(id)%ShardCount]
+}
+
+func (c *ChainCache) Get(id string) *DiskStatus {
+	if len(c.Members) == 0 {
+		return nil
+	}
+	if status := c.Members[0].Get(id); status != nil {
+		return status
+	}
+	// The later members are read under the lock, so no Update can land
+	// between the read and the backfill
+	mu := c.lock(id)
+	mu.Lock()
+	defer mu.Unlock()
+	for i, member := range c.Members[1:] {
+		status := member.Get(id)
+		if status == nil {
+			continue
+		}
+		for _, earlier := range c.Members[:i+1] {
+			earlier.Update(id, status)
+		}
+		return status
+	}
+	return nil
+}
+
+// Update writes every member, last first, under id's lock, so a member never
+// holds a value the ones behind it don't have yet.
+func (c *ChainCache) Update(id string, status *DiskStatus) {
+	mu := c.lock(id)
+	mu.Lock()
+	defer mu.Unlock()
+	for i := len(c.Members) - 1; i >= 0; i-- {
+		c.Members[i].Update(id, status)
+	}
+}
@@ -0,0 +1,56 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestChainCache(t *testing.T) {
+	local, remote := NewMutexCache(), NewMutexCache()
+	c := NewChainCache(local, remote)
+	status := &DiskStatus{ID: "disk-1", Temp: 40}
+	remote.Update("disk-1", status)
+
+	if got := c.Get("disk-1"); got != status {
+		t.Fatalf("expected %v from the second member, got %v", status, got)
+	}
+	if got := local.Get("disk-1"); got != status {
+		t.Errorf("expected the first member to be populated, got %v", got)
+	}
+	if got := c.Get("missing"); got != nil {
+		t.Errorf("expected nil for a missing key, got %v", got)
+	}
+
+	c.Update("disk-2", &DiskStatus{ID: "disk-2"})
+	if !local.Contains("disk-2") || !remote.Contains("disk-2") {
+		t.Errorf("expected Update to write every member")
+	}
+}
+
+// A Get reads the old value from the last member while an Update replaces
+// it; the Get must not copy the old value into the earlier members afterwards
+func TestChainCacheNoStaleBackfill(t *testing.T) {
+	local, middle := NewMutexCache(), NewMutexCache()
+	remote := newPausingCache(NewMutexCache())
+	c := NewChainCache(local, middle, remote)
+	remote.Cache.Update("disk-1", &DiskStatus{ID: "disk-1", Temp: 40})
+
+	got := make(chan *DiskStatus)
+	go func() { got <- c.Get("disk-1") }()
+	<-remote.read
+	updated := make(chan struct{})
+	latest := &DiskStatus{ID: "disk-1", Temp: 50}
+	go func() {
+		c.Update("disk-1", latest)
+		close(updated)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	close(remote.release)
+	<-got
+	<-updated
+	for i, member := range []Cache{local, middle} {
+		if status := member.Get("disk-1"); status != latest {
+			t.Errorf("expected member %d to hold the latest value, got %v", i, status)
+		}
+	}
+}
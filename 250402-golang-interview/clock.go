@@ -0,0 +1,125 @@
+package cache
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// 14. Clock Cache (bounded)
+//
+// The clock (second-chance) approximation of LRU. Entries sit in a circular
+// buffer of slots, each with a reference bit that Get sets. Eviction sweeps a
+// hand around the buffer, clearing set bits until it reaches a slot whose bit
+// is clear. Reads never reorder anything, so they only need the read lock and
+// one atomic store, where LRUCache.Get needs the exclusive lock to move a list
+// element.
+type ClockCache struct {
+	mu    sync.RWMutex
+	slots []clockSlot
+	index map[string]int // id -> slot
+	hand  int
+	size  int // capacity in slots
+}
+
+type clockSlot struct {
+	id     string
+	status *DiskStatus
+	ref    atomic.Bool
+}
+
+var _ Cache = (*ClockCache)(nil)
+
+// NewClockCache returns a cache holding at most maxEntries entries, at least
+// one.
+func NewClockCache(maxEntries int) *ClockCache {
+	size := max(maxEntries, 1)
+	return &ClockCache{
+		slots: make([]clockSlot, 0, size),
+		index: make(map[string]int, size),
+		size:  size,
+	}
+}
+
+func (c *ClockCache) Get(id string) *DiskStatus {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	i, ok := c.index[id]
+	if !ok {
+		return nil
+	}
+	slot := &c.slots[i]
+	// Skip the store when the bit is already set, so hot keys don't keep
+	// writing to a shared cache line
+	if !slot.ref.Load() {
+		slot.ref.Store(true)
+	}
+	return slot.status
+}
+
+// Update stores status, evicting the first unreferenced entry from the hand
+// onwards if the cache is full. Overwriting an existing key counts as an
+// access; a new key starts unreferenced.
+func (c *ClockCache) Update(id string, status *DiskStatus) {
+	if status == nil {
+		c.Delete(id)
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if i, ok := c.index[id]; ok {
+		c.slots[i].status = status
+		c.slots[i].ref.Store(true)
+		return
+	}
+	if len(c.slots) < c.size {
+		c.slots = append(c.slots, clockSlot{id: id, status: status})
+		c.index[id] = len(c.slots) - 1
+		return
+	}
+	for c.slots[c.hand].ref.Load() {
+		c.slots[c.hand].ref.Store(false)
+		c.hand = (c.hand + 1) % c.size
+	}
+	slot := &c.slots[c.hand]
+	delete(c.index, slot.id)
+	slot.id, slot.status = id, status
+	c.index[id] = c.hand
+	c.hand = (c.hand + 1) % c.size
+}
+
+// Contains reports presence without setting the reference bit.
+func (c *ClockCache) Contains(id string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	_, ok := c.index[id]
+	return ok
+}
+
+// Delete moves the last slot into the freed one so the buffer stays dense.
+func (c *ClockCache) Delete(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i, ok := c.index[id]
+	if !ok {
+		return
+	}
+	delete(c.index, id)
+	last := len(c.slots) - 1
+	if i != last {
+		moved := &c.slots[last]
+		c.slots[i].id, c.slots[i].status = moved.id, moved.status
+		c.slots[i].ref.Store(moved.ref.Load())
+		c.index[moved.id] = i
+	}
+	c.slots[last] = clockSlot{}
+	c.slots = c.slots[:last]
+	if c.hand >= len(c.slots) {
+		c.hand = 0
+	}
+}
+
+func (c *ClockCache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.slots)
+}
@@ -0,0 +1,17 @@
+package cache
+
+import "io"
+
+// Closeable is implemented by caches that own background goroutines
+// (janitors, flushers, refreshes), which leak unless Close is called. Close is
+// idempotent and returns only once those goroutines have exited. Callers
+// holding a plain Cache can type-assert to it to shut one down generically.
+type Closeable interface {
+	io.Closer
+}
+
+var (
+	_ Closeable = (*MutexCache)(nil)
+	_ Closeable = (*WriteBehindCache)(nil)
+	_ Closeable = (*LoadingCache)(nil)
+)
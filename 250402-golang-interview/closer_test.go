@@ -0,0 +1,121 @@
+package cache
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+// expectGoroutines fails unless the goroutine count drops back to at most
+// want. An exiting goroutine can linger in the count briefly, so it polls.
+func expectGoroutines(t *testing.T, want int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > want {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected at most %d goroutines after Close, got %d", want, runtime.NumGoroutine())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCloseStopsGoroutines(t *testing.T) {
+	t.Run("MutexCacheJanitors", func(t *testing.T) {
+		before := runtime.NumGoroutine()
+		c := NewMutexCache()
+		c.StartJanitor(time.Millisecond)
+		c.StartJanitor(time.Hour)
+		if runtime.NumGoroutine() < before+2 {
+			t.Fatalf("expected two janitor goroutines")
+		}
+		var closer Closeable = c
+		if err := closer.Close(); err != nil {
+			t.Fatal(err)
+		}
+		expectGoroutines(t, before)
+		closer.Close() // idempotent
+	})
+
+	t.Run("WriteBehindCache", func(t *testing.T) {
+		before := runtime.NumGoroutine()
+		c := NewWriteBehindCache(NewMutexCache(), func(map[string]*DiskStatus) error { return nil }, time.Millisecond)
+		c.Update("disk-1", &DiskStatus{ID: "disk-1"})
+		c.Close()
+		expectGoroutines(t, before)
+		c.Close()
+	})
+
+	t.Run("LoadingCacheRefreshes", func(t *testing.T) {
+		clock := newFakeClock()
+		release := make(chan struct{})
+		loads := 0
+		c := NewLoadingCache(NewMutexCache(), func(id string) (*DiskStatus, error) {
+			loads++
+			if loads > 1 {
+				<-release
+			}
+			return &DiskStatus{ID: id}, nil
+		}, WithRefreshAfter(time.Minute))
+		c.now = clock.Now
+		c.Get("disk-1")
+
+		before := runtime.NumGoroutine()
+		clock.Advance(time.Minute)
+		c.Get("disk-1") // starts a refresh that blocks on release
+
+		closed := make(chan struct{})
+		go func() {
+			c.Close()
+			close(closed)
+		}()
+		select {
+		case <-closed:
+			t.Fatalf("expected Close to wait for the running refresh")
+		case <-time.After(10 * time.Millisecond):
+		}
+		close(release)
+		<-closed
+		expectGoroutines(t, before)
+
+		// No new refreshes once closed
+		clock.Advance(time.Minute)
+		c.Get("disk-1")
+		expectGoroutines(t, before)
+		c.Close()
+	})
+
+	t.Run("LoadingCacheTimedOutLoads", func(t *testing.T) {
+		release := make(chan struct{})
+		c := NewLoadingCache(NewMutexCache(), func(id string) (*DiskStatus, error) {
+			<-release
+			return &DiskStatus{ID: id}, nil
+		})
+		before := runtime.NumGoroutine()
+		if _, err := c.GetWithLoaderTimeout("disk-1", time.Millisecond); err != ErrLoadTimeout {
+			t.Fatalf("expected ErrLoadTimeout, got %v", err)
+		}
+
+		closed := make(chan struct{})
+		go func() {
+			c.Close()
+			close(closed)
+		}()
+		select {
+		case <-closed:
+			t.Fatalf("expected Close to wait for the timed-out load")
+		case <-time.After(10 * time.Millisecond):
+		}
+		close(release)
+		<-closed
+		expectGoroutines(t, before)
+
+		// Once closed a miss fails fast rather than risk blocking past timeout
+		if status, err := c.GetWithLoaderTimeout("disk-2", time.Millisecond); err != ErrClosed {
+			t.Errorf("expected ErrClosed after Close, got (%v, %v)", status, err)
+		}
+		if status, err := c.GetWithLoaderTimeout("disk-1", time.Millisecond); err != nil || status == nil {
+			t.Errorf("expected a cached hit after Close, got (%v, %v)", status, err)
+		}
+		expectGoroutines(t, before)
+	})
+}
@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"cmp"
+	"hash/maphash"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+// DefaultVirtualNodes is how many ring points NewConsistentHashCache gives
+// each node. More points spread keys more evenly at the cost of a larger ring.
+const DefaultVirtualNodes = 100
+
+// ConsistentHashCache spreads keys over named backend caches (nodes) with a
+// hash ring, so adding or removing a node only remaps the keys between its
+// points and their neighbours: about 1/N of them, where modulo routing would
+// remap nearly all. Entries aren't migrated; a remapped key misses on its new
+// node until it is written again.
+type ConsistentHashCache struct {
+	vnodes int
+	seed   maphash.Seed
+
+	mu    sync.RWMutex
+	nodes map[string]Cache
+	ring  []ringPoint // sorted by hash
+}
+
+type ringPoint struct {
+	hash uint64
+	node string
+}
+
+var _ Cache = (*ConsistentHashCache)(nil)
+
+// NewConsistentHashCache returns an empty ring giving each node vnodes
+// points. vnodes <= 0 falls back to DefaultVirtualNodes.
+func NewConsistentHashCache(vnodes int) *ConsistentHashCache {
+	if vnodes <= 0 {
+		vnodes = DefaultVirtualNodes
+	}
+	return &ConsistentHashCache{
+		vnodes: vnodes,
+		seed:   maphash.MakeSeed(),
+		nodes:  make(map[string]Cache),
+	}
+}
+
+// AddNode adds c to the ring under name, replacing any node of that name.
+func (c *ConsistentHashCache) AddNode(name string, node Cache) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.nodes[name]; ok {
+		c.removeLocked(name)
+	}
+	c.nodes[name] = node
+	for i := 0; i < c.vnodes; i++ {
+		c.ring = append(c.ring, ringPoint{maphash.String(c.seed, name+"#"+strconv.Itoa(i)), name})
+	}
+	slices.SortFunc(c.ring, func(a, b ringPoint) int { return cmp.Compare(a.hash, b.hash) })
+}
+
+// RemoveNode takes name off the ring; its keys move to the next node along.
+func (c *ConsistentHashCache) RemoveNode(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.removeLocked(name)
+}
+
+func (c *ConsistentHashCache) removeLocked(name string) {
+	delete(c.nodes, name)
+	c.ring = slices.DeleteFunc(c.ring, func(p ringPoint) bool { return p.node == name })
+}
+
+// nodeFor returns the node owning id: the first ring point at or after id's
+// hash, wrapping around. It is nil while the ring is empty.
+func (c *ConsistentHashCache) nodeFor(id string) (string, Cache) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if len(c.ring) == 0 {
+		return "", nil
+	}
+	h := maphash.String(c.seed, id)
+	i, _ := slices.BinarySearchFunc(c.ring, h, func(p ringPoint, h uint64) int { return cmp.Compare(p.hash, h) })
+	if i == len(c.ring) {
+		i = 0
+	}
+	name := c.ring[i].node
+	return name, c.nodes[name]
+}
+
+// Get returns nil while no nodes are registered.
+func (c *ConsistentHashCache) Get(id string) *DiskStatus {
+	_, node := c.nodeFor(id)
+	if node == nil {
+		return nil
+	}
+	return node.Get(id)
+}
+
+// Update drops the write while no nodes are registered.
+func (c *ConsistentHashCache) Update(id string, status *DiskStatus) {
+	if _, node := c.nodeFor(id); node != nil {
+		node.Update(id, status)
+	}
+}
@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestConsistentHashCache(t *testing.T) {
+	const nodes, keys = 4, 10000
+	c := NewConsistentHashCache(0)
+	for i := 0; i < nodes; i++ {
+		c.AddNode(fmt.Sprintf("node-%d", i), NewShardedCache())
+	}
+	owners := func() map[string]string {
+		m := make(map[string]string, keys)
+		for i := 0; i < keys; i++ {
+			id := fmt.Sprintf("disk-%d", i)
+			m[id], _ = c.nodeFor(id)
+		}
+		return m
+	}
+
+	t.Run("Routing", func(t *testing.T) {
+		status := &DiskStatus{ID: "disk-1", Temp: 40}
+		c.Update("disk-1", status)
+		if got := c.Get("disk-1"); got != status {
+			t.Errorf("expected %v, got %v", status, got)
+		}
+		_, node := c.nodeFor("disk-1")
+		if got := node.Get("disk-1"); got != status {
+			t.Errorf("expected the owning node to hold disk-1, got %v", got)
+		}
+	})
+
+	t.Run("AddNodeRemapsFraction", func(t *testing.T) {
+		before := owners()
+		c.AddNode("node-new", NewShardedCache())
+		after := owners()
+
+		moved := 0
+		for id, owner := range after {
+			if owner != before[id] {
+				moved++
+				if owner != "node-new" {
+					t.Fatalf("expected %s to move only to the new node, got %s", id, owner)
+				}
+			}
+		}
+		want := 1.0 / (nodes + 1)
+		if got := float64(moved) / keys; got < want*0.6 || got > want*1.4 {
+			t.Errorf("expected about %.2f of keys remapped, got %.2f", want, got)
+		}
+
+		// Removing it sends exactly those keys back
+		c.RemoveNode("node-new")
+		for id, owner := range owners() {
+			if owner != before[id] {
+				t.Fatalf("expected %s back on %s, got %s", id, before[id], owner)
+			}
+		}
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		c := NewConsistentHashCache(0)
+		c.Update("disk-1", &DiskStatus{ID: "disk-1"})
+		if got := c.Get("disk-1"); got != nil {
+			t.Errorf("expected nil with no nodes, got %v", got)
+		}
+	})
+}
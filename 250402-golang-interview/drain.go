@@ -0,0 +1,32 @@
+package cache
+
+import "context"
+
+// Drain streams a snapshot of every entry over the returned channel, then
+// closes it. No lock is held while sending, so a slow consumer doesn't block
+// writers; entries written after the call may be missed. Cancelling ctx stops
+// the send loop early and closes the channel, so the goroutine never leaks
+// even if the consumer walks away.
+func (c *MutexCache) Drain(ctx context.Context) <-chan *DiskStatus {
+	return drain(ctx, c.Snapshot())
+}
+
+// Drain is MutexCache.Drain over a snapshot taken one shard at a time.
+func (c *ShardedCache) Drain(ctx context.Context) <-chan *DiskStatus {
+	return drain(ctx, c.Snapshot())
+}
+
+func drain(ctx context.Context, snap map[string]*DiskStatus) <-chan *DiskStatus {
+	ch := make(chan *DiskStatus)
+	go func() {
+		defer close(ch)
+		for _, status := range snap {
+			select {
+			case ch <- status:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
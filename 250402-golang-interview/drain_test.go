@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDrain(t *testing.T) {
+	caches := []struct {
+		name string
+		new  func() Cache
+	}{
+		{"MutexCache", func() Cache { return NewMutexCache() }},
+		{"ShardedCache", func() Cache { return NewShardedCache() }},
+	}
+	type drainer interface {
+		Cache
+		Drain(ctx context.Context) <-chan *DiskStatus
+	}
+
+	for _, tc := range caches {
+		t.Run(tc.name+"/All", func(t *testing.T) {
+			c := initCache(tc.new()).(drainer)
+			seen := make(map[string]bool)
+			for status := range c.Drain(context.Background()) {
+				seen[status.ID] = true
+			}
+			if len(seen) != numKeys {
+				t.Errorf("expected %d distinct entries, got %d", numKeys, len(seen))
+			}
+		})
+
+		t.Run(tc.name+"/Cancel", func(t *testing.T) {
+			c := initCache(tc.new()).(drainer)
+			ctx, cancel := context.WithCancel(context.Background())
+			ch := c.Drain(ctx)
+			<-ch
+			cancel()
+
+			// The channel must close promptly without being read to the end
+			n := 1
+			timeout := time.After(time.Second)
+			for {
+				select {
+				case _, ok := <-ch:
+					if !ok {
+						if n >= numKeys {
+							t.Errorf("expected cancel to stop early, got all %d entries", n)
+						}
+						return
+					}
+					n++
+				case <-timeout:
+					t.Fatalf("channel not closed after cancel")
+				}
+			}
+		})
+	}
+}
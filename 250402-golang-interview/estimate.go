@@ -0,0 +1,31 @@
+package cache
+
+import "unsafe"
+
+// mapSlotBytes approximates what one entry costs inside a
+// map[string]*DiskStatus: the string header and pointer in its slot, plus a
+// share of the control bytes and of the slack the map keeps below its maximum
+// load factor.
+const mapSlotBytes = (unsafe.Sizeof("")+unsafe.Sizeof((*DiskStatus)(nil)))*8/7 + 1
+
+// EstimatedBytes approximates the cache's memory footprint for capacity
+// planning: per entry, a map slot, the DiskStatus it points to and the key's
+// bytes, plus the fixed cost of the shards. It ignores allocator rounding and
+// assumes each status's ID shares its bytes with the key, so expect it to be
+// off by a constant factor but to scale with the contents.
+func (c *ShardedCache) EstimatedBytes() int64 {
+	t := c.base.table.Load()
+	total := int64(len(t.shards)) * int64(unsafe.Sizeof(shard[*DiskStatus]{}))
+	for i := range t.shards {
+		shard := &t.shards[i]
+		shard.mu.RLock()
+		for id, status := range shard.data {
+			total += int64(mapSlotBytes) + int64(len(id))
+			if status != nil {
+				total += int64(unsafe.Sizeof(*status))
+			}
+		}
+		shard.mu.RUnlock()
+	}
+	return total
+}
@@ -0,0 +1,30 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestShardedCacheEstimatedBytes(t *testing.T) {
+	fill := func(n int) int64 {
+		c := NewShardedCache()
+		for i := 0; i < n; i++ {
+			// Fixed-width ids, so every entry costs the same
+			id := fmt.Sprintf("disk-%06d", i)
+			c.Update(id, &DiskStatus{ID: id})
+		}
+		return c.EstimatedBytes()
+	}
+
+	empty := fill(0)
+	if empty <= 0 {
+		t.Fatalf("expected a fixed cost for the shards, got %d", empty)
+	}
+	one, two := fill(1000)-empty, fill(2000)-empty
+	if one <= 0 {
+		t.Fatalf("expected entries to add to the estimate, got %d", one)
+	}
+	if ratio := float64(two) / float64(one); ratio < 1.9 || ratio > 2.1 {
+		t.Errorf("expected twice the entries to cost about twice as much, got %.2fx (%d vs %d)", ratio, two, one)
+	}
+}
@@ -0,0 +1,39 @@
+package cache
+
+// evictions queues entries removed while a cache's lock is held, so the
+// OnEvict callback can run after the lock is released and is free to call
+// back into the cache.
+type evictions struct {
+	fn      func(id string, status *DiskStatus)
+	pending []evicted
+}
+
+type evicted struct {
+	id     string
+	status *DiskStatus
+}
+
+// add records a removal. It's a no-op when no callback is registered, so
+// caches without OnEvict pay nothing. Callers must hold the cache lock.
+func (e *evictions) add(id string, status *DiskStatus) {
+	if e.fn != nil {
+		e.pending = append(e.pending, evicted{id, status})
+	}
+}
+
+// take hands back the callback and the queued removals, resetting the queue.
+// Callers must hold the cache lock.
+func (e *evictions) take() (func(id string, status *DiskStatus), []evicted) {
+	if len(e.pending) == 0 {
+		return nil, nil
+	}
+	pending := e.pending
+	e.pending = nil
+	return e.fn, pending
+}
+
+func notify(fn func(id string, status *DiskStatus), removed []evicted) {
+	for _, e := range removed {
+		fn(e.id, e.status)
+	}
+}
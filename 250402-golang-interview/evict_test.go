@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// evictRecorder collects OnEvict calls
+type evictRecorder struct {
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func newEvictRecorder() *evictRecorder {
+	return &evictRecorder{calls: make(map[string]int)}
+}
+
+func (r *evictRecorder) record(id string, status *DiskStatus) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if status == nil || status.ID != id {
+		panic(fmt.Sprintf("evicted %s with status %v", id, status))
+	}
+	r.calls[id]++
+}
+
+func (r *evictRecorder) expect(t *testing.T, want map[string]int) {
+	t.Helper()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.calls) != len(want) {
+		t.Errorf("expected evictions %v, got %v", want, r.calls)
+		return
+	}
+	for id, n := range want {
+		if r.calls[id] != n {
+			t.Errorf("expected %s evicted %d times, got %d", id, n, r.calls[id])
+		}
+	}
+}
+
+func TestMutexCacheOnEvict(t *testing.T) {
+	clock := newFakeClock()
+	c := NewMutexCache()
+	c.now = clock.Now
+	rec := newEvictRecorder()
+	c.OnEvict(rec.record)
+
+	for i := 0; i < 4; i++ {
+		id := fmt.Sprintf("disk-%d", i)
+		c.UpdateWithTTL(id, &DiskStatus{ID: id}, time.Minute)
+	}
+	c.Update("kept", &DiskStatus{ID: "kept"})
+	c.Update("deleted", &DiskStatus{ID: "deleted"})
+
+	c.Delete("deleted")
+	c.Delete("deleted") // already gone, must not fire again
+	clock.Advance(time.Minute)
+	c.Get("disk-0") // lazy expiry
+	c.Get("disk-0") // already removed
+	c.sweep()       // janitor expiry of the rest
+	c.GetAndDelete("kept")
+
+	rec.expect(t, map[string]int{
+		"deleted": 1, "disk-0": 1, "disk-1": 1, "disk-2": 1, "disk-3": 1,
+	})
+}
+
+func TestLRUCacheOnEvict(t *testing.T) {
+	c := NewLRUCache(2)
+	rec := newEvictRecorder()
+
+	// The callback may re-enter the cache without deadlocking
+	c.OnEvict(func(id string, status *DiskStatus) {
+		c.Len()
+		rec.record(id, status)
+	})
+
+	for i := 0; i < 4; i++ {
+		id := fmt.Sprintf("disk-%d", i)
+		c.Update(id, &DiskStatus{ID: id})
+	}
+	c.Delete("disk-3")
+
+	rec.expect(t, map[string]int{"disk-0": 1, "disk-1": 1, "disk-3": 1})
+}
+
+func TestLFUCacheOnEvict(t *testing.T) {
+	c := NewLFUCache(2)
+	rec := newEvictRecorder()
+	c.OnEvict(rec.record)
+
+	c.Update("hot", &DiskStatus{ID: "hot"})
+	c.Get("hot")
+	c.Update("cold", &DiskStatus{ID: "cold"})
+	c.Update("new", &DiskStatus{ID: "new"})
+
+	rec.expect(t, map[string]int{"cold": 1})
+}
@@ -0,0 +1,49 @@
+package cache
+
+import "expvar"
+
+// PublishExpvar registers the cache's size and Stats counters on /debug/vars
+// under name. The values are read on every scrape, so they are always
+// current. Like expvar.Publish, it panics if name is already registered.
+func (c *MutexCache) PublishExpvar(name string) { publishExpvar(name, c) }
+
+func (c *RWMutexCache) PublishExpvar(name string) { publishExpvar(name, c) }
+
+func (c *ShardedCache) PublishExpvar(name string) { publishExpvar(name, c) }
+
+func (c *SyncMapCache) PublishExpvar(name string) { publishExpvar(name, c) }
+
+func (c *StripedSyncMapCache) PublishExpvar(name string) { publishExpvar(name, c) }
+
+func (c *SpinLockCache) PublishExpvar(name string) { publishExpvar(name, c) }
+
+func (c *COWCache) PublishExpvar(name string) { publishExpvar(name, c) }
+
+func (c *HybridCache) PublishExpvar(name string) { publishExpvar(name, c) }
+
+func (c *SeqLockCache) PublishExpvar(name string) { publishExpvar(name, c) }
+
+func (c *ShardedCOWCache) PublishExpvar(name string) { publishExpvar(name, c) }
+
+// expvarStats is the JSON shape published for each cache.
+type expvarStats struct {
+	Len     int    `json:"len"`
+	Hits    uint64 `json:"hits"`
+	Misses  uint64 `json:"misses"`
+	Updates uint64 `json:"updates"`
+}
+
+func publishExpvar(name string, c interface {
+	Len() int
+	Stats() Stats
+}) {
+	expvar.Publish(name, expvar.Func(func() any {
+		s := c.Stats()
+		return expvarStats{
+			Len:     c.Len(),
+			Hits:    s.Hits,
+			Misses:  s.Misses,
+			Updates: s.Updates,
+		}
+	}))
+}
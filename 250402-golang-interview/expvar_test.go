@@ -0,0 +1,39 @@
+package cache
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+)
+
+func TestPublishExpvar(t *testing.T) {
+	c := NewShardedCache()
+	c.PublishExpvar("test_sharded_cache")
+
+	read := func() expvarStats {
+		t.Helper()
+		v := expvar.Get("test_sharded_cache")
+		if v == nil {
+			t.Fatal("expected test_sharded_cache to be published")
+		}
+		var got expvarStats
+		if err := json.Unmarshal([]byte(v.String()), &got); err != nil {
+			t.Fatalf("invalid expvar JSON %q: %v", v.String(), err)
+		}
+		return got
+	}
+
+	if got := read(); got != (expvarStats{}) {
+		t.Errorf("expected zero stats for a fresh cache, got %+v", got)
+	}
+
+	c.Update("disk-1", &DiskStatus{ID: "disk-1"})
+	c.Update("disk-2", &DiskStatus{ID: "disk-2"})
+	c.Get("disk-1")
+	c.Get("absent")
+
+	want := expvarStats{Len: 2, Hits: 1, Misses: 1, Updates: 2}
+	if got := read(); got != want {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+}
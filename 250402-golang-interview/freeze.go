@@ -0,0 +1,34 @@
+package cache
+
+// Freeze makes the cache read-only for maintenance: every write method does
+// nothing until Unfreeze, while reads carry on. Methods that report success
+// return false, Swap and GetAndDelete return nil, the Increment methods return
+// the unchanged value, and GetOrCompute returns compute() without storing it.
+// Writes check the flag under the lock they already take, and Freeze waits for
+// that lock, so no write that started before Freeze lands after it returns.
+func (c *MutexCache) Freeze() {
+	c.frozen.Store(true)
+	c.lock()
+	c.unlock()
+}
+
+func (c *MutexCache) Unfreeze() {
+	c.frozen.Store(false)
+}
+
+// Freeze is MutexCache.Freeze. It waits for every shard lock in turn, and for
+// multi-shard writers such as UpdateBatch.
+func (c *ShardedCache) Freeze() {
+	c.frozen.Store(true)
+	c.base.resizeMu.Lock()
+	defer c.base.resizeMu.Unlock()
+	t := c.base.table.Load()
+	for i := range t.shards {
+		t.shards[i].mu.Lock()
+		t.shards[i].mu.Unlock()
+	}
+}
+
+func (c *ShardedCache) Unfreeze() {
+	c.frozen.Store(false)
+}
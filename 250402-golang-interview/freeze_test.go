@@ -0,0 +1,161 @@
+package cache
+
+import (
+	"maps"
+	"testing"
+	"time"
+)
+
+func TestFreeze(t *testing.T) {
+	caches := []struct {
+		name string
+		c    interface {
+			Cache
+			UpdateOK(id string, status *DiskStatus) bool
+			UpdateBatch(items map[string]*DiskStatus)
+			Delete(id string)
+			Freeze()
+			Unfreeze()
+		}
+	}{
+		{"MutexCache", NewMutexCache()},
+		{"ShardedCache", NewShardedCache()},
+	}
+
+	for _, tc := range caches {
+		t.Run(tc.name, func(t *testing.T) {
+			c := tc.c
+			before := &DiskStatus{ID: "disk-1", Temp: 30}
+			c.Update("disk-1", before)
+			c.Freeze()
+
+			if c.UpdateOK("disk-1", &DiskStatus{ID: "disk-1", Temp: 31}) {
+				t.Errorf("expected UpdateOK to be rejected while frozen")
+			}
+			c.Update("disk-1", &DiskStatus{ID: "disk-1", Temp: 32})
+			c.UpdateBatch(map[string]*DiskStatus{"disk-2": {ID: "disk-2"}})
+			c.Delete("disk-1")
+			if got := c.Get("disk-1"); got != before {
+				t.Errorf("expected reads to serve %v while frozen, got %v", before, got)
+			}
+			if got := c.Get("disk-2"); got != nil {
+				t.Errorf("expected UpdateBatch to be rejected, got %v", got)
+			}
+
+			c.Unfreeze()
+			after := &DiskStatus{ID: "disk-1", Temp: 33}
+			if !c.UpdateOK("disk-1", after) {
+				t.Errorf("expected UpdateOK to succeed after Unfreeze")
+			}
+			if got := c.Get("disk-1"); got != after {
+				t.Errorf("expected %v, got %v", after, got)
+			}
+		})
+	}
+}
+
+// freezeWrite is one write method called on a frozen cache, with the result
+// it must report
+type freezeWrite struct {
+	name  string
+	write func(c writeCache) any
+	want  any
+}
+
+// writeCache is the write API MutexCache and ShardedCache share
+type writeCache interface {
+	Cache
+	Contains(id string) bool
+	Len() int
+	UpdateOK(id string, status *DiskStatus) bool
+	UpdateBatch(items map[string]*DiskStatus)
+	CompareAndUpdate(id string, old, new *DiskStatus) bool
+	UpdateIfAbsent(id string, status *DiskStatus) bool
+	Swap(id string, status *DiskStatus) *DiskStatus
+	UpdateFunc(id string, fn func(*DiskStatus) *DiskStatus)
+	IncrementTemp(id string, delta int) int
+	IncrementHealth(id string, delta int) int
+	GetAndDelete(id string) *DiskStatus
+	GetOrCompute(id string, compute func() *DiskStatus) *DiskStatus
+	Delete(id string)
+	Clear()
+	Snapshot() map[string]*DiskStatus
+	Freeze()
+	SetKeyNormalizer(fn func(string) string)
+}
+
+func TestFreezeRejectsEveryWrite(t *testing.T) {
+	next := &DiskStatus{ID: "disk-2", Temp: 50}
+	common := []freezeWrite{
+		{"Update", func(c writeCache) any { c.Update("disk-2", next); return nil }, nil},
+		{"UpdateNil", func(c writeCache) any { c.Update("disk-1", nil); return nil }, nil},
+		{"UpdateOK", func(c writeCache) any { return c.UpdateOK("disk-2", next) }, false},
+		{"UpdateBatch", func(c writeCache) any {
+			c.UpdateBatch(map[string]*DiskStatus{"disk-2": next})
+			return nil
+		}, nil},
+		{"CompareAndUpdate", func(c writeCache) any {
+			return c.CompareAndUpdate("disk-1", c.Get("disk-1"), next)
+		}, false},
+		{"UpdateIfAbsent", func(c writeCache) any { return c.UpdateIfAbsent("disk-2", next) }, false},
+		{"Swap", func(c writeCache) any { return c.Swap("disk-1", next) }, (*DiskStatus)(nil)},
+		{"UpdateFunc", func(c writeCache) any {
+			c.UpdateFunc("disk-1", func(*DiskStatus) *DiskStatus { return next })
+			return nil
+		}, nil},
+		{"IncrementTemp", func(c writeCache) any { return c.IncrementTemp("disk-1", 5) }, 30},
+		{"IncrementHealth", func(c writeCache) any { return c.IncrementHealth("disk-2", 5) }, 0},
+		{"GetAndDelete", func(c writeCache) any { return c.GetAndDelete("disk-1") }, (*DiskStatus)(nil)},
+		{"GetOrCompute", func(c writeCache) any {
+			return c.GetOrCompute("disk-2", func() *DiskStatus { return next })
+		}, next},
+		{"Delete", func(c writeCache) any { c.Delete("disk-1"); return nil }, nil},
+		{"Clear", func(c writeCache) any { c.Clear(); return nil }, nil},
+	}
+
+	caches := []struct {
+		name  string
+		new   func() writeCache
+		extra []freezeWrite
+	}{
+		{"MutexCache", func() writeCache { return NewMutexCache() }, []freezeWrite{
+			{"UpdateWithTTL", func(c writeCache) any {
+				c.(*MutexCache).UpdateWithTTL("disk-2", next, time.Minute)
+				return nil
+			}, nil},
+			{"Touch", func(c writeCache) any { return c.(*MutexCache).Touch("disk-1", time.Minute) }, false},
+			{"ReplaceAll", func(c writeCache) any {
+				c.(*MutexCache).ReplaceAll(map[string]*DiskStatus{"disk-2": next})
+				return nil
+			}, nil},
+		}},
+		{"ShardedCache", func() writeCache { return NewShardedCache() }, []freezeWrite{
+			{"Rename", func(c writeCache) any { return c.(*ShardedCache).Rename("disk-1", "disk-2") }, false},
+			{"WithShard", func(c writeCache) any {
+				c.(*ShardedCache).WithShard("disk-1", func(m map[string]*DiskStatus) { delete(m, "disk-1") })
+				return nil
+			}, nil},
+			{"UpdateFields", func(c writeCache) any {
+				c.(*ShardedCache).UpdateFields("disk-2", 1, 2)
+				return nil
+			}, nil},
+		}},
+	}
+
+	for _, tc := range caches {
+		for _, w := range append(common, tc.extra...) {
+			t.Run(tc.name+"/"+w.name, func(t *testing.T) {
+				c := tc.new()
+				c.Update("disk-1", &DiskStatus{ID: "disk-1", Temp: 30})
+				c.Freeze()
+				if got := w.write(c); got != w.want {
+					t.Errorf("expected %v, got %v", w.want, got)
+				}
+				want := map[string]*DiskStatus{"disk-1": {ID: "disk-1", Temp: 30}}
+				if got := c.Snapshot(); !maps.EqualFunc(got, want, func(a, b *DiskStatus) bool { return *a == *b }) {
+					t.Errorf("expected the cache unchanged, got %v", got)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,900 @@
+package cache
+
+import (
+	"container/list"
+	"hash/maphash"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Generic versions of the seven caches for arbitrary value types.
+//
+// Get returns (V, bool) so a stored zero value can be told apart from a
+// missing key, which the *DiskStatus API can only express as nil.
+//
+// These hold the storage and locking of each strategy. The *DiskStatus caches
+// are thin wrappers around them: each keeps one as its base, delegates plain
+// reads and writes to it, and adds its own methods (stats, TTLs, freezing,
+// OnEvict, ...) on top, reaching into the base's lock and map for the
+// compound ones. They can't be aliases such as
+// type MutexCache = GenericMutexCache[*DiskStatus], since Get would then
+// return (*DiskStatus, bool) and break the Cache interface, and an
+// instantiated generic type can't be given extra methods.
+type GenericCache[V any] interface {
+	Get(id string) (V, bool)
+	Update(id string, v V)
+}
+
+var (
+	_ GenericCache[int] = (*GenericMutexCache[int])(nil)
+	_ GenericCache[int] = (*GenericRWMutexCache[int])(nil)
+	_ GenericCache[int] = (*GenericShardedCache[int])(nil)
+	_ GenericCache[int] = (*GenericSyncMapCache[int])(nil)
+	_ GenericCache[int] = (*GenericSpinLockCache[int])(nil)
+	_ GenericCache[int] = (*GenericCOWCache[int])(nil)
+	_ GenericCache[int] = (*GenericHybridCache[int])(nil)
+)
+
+// 1. Basic Mutex Cache
+type GenericMutexCache[V any] struct {
+	mu   sync.Mutex
+	data map[string]V
+}
+
+func NewGenericMutexCache[V any]() *GenericMutexCache[V] {
+	return &GenericMutexCache[V]{data: make(map[string]V)}
+}
+
+func (c *GenericMutexCache[V]) Get(id string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	v, ok := c.data[id]
+	return v, ok
+}
+
+func (c *GenericMutexCache[V]) Update(id string, v V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.data[id] = v
+}
+
+func (c *GenericMutexCache[V]) Delete(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.data, id)
+}
+
+func (c *GenericMutexCache[V]) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.data = make(map[string]V)
+}
+
+func (c *GenericMutexCache[V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.data)
+}
+
+// Range calls fn for each entry until fn returns false. The lock is held for
+// the whole walk, so fn must not call back into the cache.
+func (c *GenericMutexCache[V]) Range(fn func(id string, v V) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for id, v := range c.data {
+		if !fn(id, v) {
+			return
+		}
+	}
+}
+
+// 2. RWMutex Cache
+type GenericRWMutexCache[V any] struct {
+	mu   sync.RWMutex
+	data map[string]V
+}
+
+func NewGenericRWMutexCache[V any]() *GenericRWMutexCache[V] {
+	return &GenericRWMutexCache[V]{data: make(map[string]V)}
+}
+
+func (c *GenericRWMutexCache[V]) Get(id string) (V, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	v, ok := c.data[id]
+	return v, ok
+}
+
+func (c *GenericRWMutexCache[V]) Update(id string, v V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.data[id] = v
+}
+
+func (c *GenericRWMutexCache[V]) Delete(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.data, id)
+}
+
+func (c *GenericRWMutexCache[V]) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.data = make(map[string]V)
+}
+
+func (c *GenericRWMutexCache[V]) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.data)
+}
+
+// Range calls fn for each entry until fn returns false. The read lock is held
+// for the whole walk, so fn must not write to the cache or it deadlocks.
+func (c *GenericRWMutexCache[V]) Range(fn func(id string, v V) bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for id, v := range c.data {
+		if !fn(id, v) {
+			return
+		}
+	}
+}
+
+// 3. Sharded Lock Cache
+const ShardCount = 32 // default shard count
+
+type shard[V any] struct {
+	mu   shardMu
+	data map[string]V
+}
+
+// shardMu counts its acquisitions when counted is set, so ShardedCache's
+// UpdateFields can tell whether anyone could have read the struct it stored
+// last. UpdateFields itself locks the embedded RWMutex directly and isn't
+// counted. Only caches built by NewRecyclingShardedCache count, so the others'
+// reads pay no atomic add.
+type shardMu struct {
+	sync.RWMutex
+	counted bool // set before the shard is shared, never changed
+	locks   atomic.Uint64
+}
+
+func (m *shardMu) Lock() {
+	m.RWMutex.Lock()
+	if m.counted {
+		m.locks.Add(1)
+	}
+}
+
+func (m *shardMu) RLock() {
+	m.RWMutex.RLock()
+	if m.counted {
+		m.locks.Add(1)
+	}
+}
+
+func (m *shardMu) TryLock() bool {
+	if !m.RWMutex.TryLock() {
+		return false
+	}
+	if m.counted {
+		m.locks.Add(1)
+	}
+	return true
+}
+
+func (m *shardMu) TryRLock() bool {
+	if !m.RWMutex.TryRLock() {
+		return false
+	}
+	if m.counted {
+		m.locks.Add(1)
+	}
+	return true
+}
+
+// shardTable is one generation of shards. ResizeShards replaces the whole
+// table; retired is set under every shard lock once the entries have moved
+// to the replacement, so a caller that locked a shard of the old table can
+// tell it lost the race and retry.
+type shardTable[V any] struct {
+	shards  []shard[V]
+	retired bool
+}
+
+// newShardTable makes n empty shards, counting their lock acquisitions if
+// counted is set.
+func newShardTable[V any](n int, counted bool) *shardTable[V] {
+	t := &shardTable[V]{shards: make([]shard[V], n)}
+	for i := range t.shards {
+		t.shards[i].data = make(map[string]V)
+		t.shards[i].mu.counted = counted
+	}
+	return t
+}
+
+type GenericShardedCache[V any] struct {
+	table     atomic.Pointer[shardTable[V]]
+	resizeMu  sync.RWMutex        // shared by multi-shard writes, exclusive in ResizeShards
+	counted   bool                // shards count their lock acquisitions, see shardMu
+	shardFunc func(id string) int // optional custom routing, see NewWeightedShardedCache
+	hashFunc  func(string) uint32 // fnv32a unless a test swaps it, see newShardedCacheWithHash
+	seeded    bool                // hash with seed instead of fnv, see NewSeededShardedCache
+	seed      maphash.Seed
+	contended atomic.Uint64 // lock acquisitions that had to wait, see MaybeRebalance
+}
+
+func NewGenericShardedCache[V any]() *GenericShardedCache[V] {
+	return NewGenericShardedCacheWithShards[V](ShardCount)
+}
+
+// NewGenericShardedCacheWithShards is NewShardedCacheWithShards for V values.
+// n <= 0 falls back to ShardCount.
+func NewGenericShardedCacheWithShards[V any](n int) *GenericShardedCache[V] {
+	c := &GenericShardedCache[V]{}
+	c.init(n, false)
+	return c
+}
+
+// init sets up an empty table of n shards (ShardCount if n <= 0) hashed with
+// fnv, for constructors that build the cache in place.
+func (c *GenericShardedCache[V]) init(n int, counted bool) {
+	if n <= 0 {
+		n = ShardCount
+	}
+	c.counted = counted
+	c.hashFunc = fnv32a
+	c.table.Store(newShardTable[V](n, counted))
+}
+
+func (c *GenericShardedCache[V]) getShard(id string) int {
+	return c.shardIndex(id, len(c.table.Load().shards))
+}
+
+// shardIndex routes id to one of n shards.
+func (c *GenericShardedCache[V]) shardIndex(id string, n int) int {
+	if c.shardFunc != nil {
+		i := c.shardFunc(id) % n
+		if i < 0 {
+			i += n
+		}
+		return i
+	}
+	if c.seeded {
+		return int(maphash.String(c.seed, id) % uint64(n))
+	}
+	return int(c.hashFunc(id) % uint32(n))
+}
+
+// lockShard write-locks id's shard in the current table, retrying if a
+// concurrent ResizeShards retired the table while we waited for the lock. A
+// failed TryLock counts as contention for MaybeRebalance; the uncontended
+// path touches no shared counter.
+func (c *GenericShardedCache[V]) lockShard(id string) *shard[V] {
+	for {
+		t := c.table.Load()
+		shard := &t.shards[c.shardIndex(id, len(t.shards))]
+		if !shard.mu.TryLock() {
+			c.contended.Add(1)
+			shard.mu.Lock()
+		}
+		if !t.retired {
+			return shard
+		}
+		shard.mu.Unlock()
+	}
+}
+
+// rlockShard is lockShard taking the read lock.
+func (c *GenericShardedCache[V]) rlockShard(id string) *shard[V] {
+	for {
+		t := c.table.Load()
+		shard := &t.shards[c.shardIndex(id, len(t.shards))]
+		if !shard.mu.TryRLock() {
+			c.contended.Add(1)
+			shard.mu.RLock()
+		}
+		if !t.retired {
+			return shard
+		}
+		shard.mu.RUnlock()
+	}
+}
+
+// lockShardUncounted is lockShard without counting the acquisition, for
+// ShardedCache.UpdateFields.
+func (c *GenericShardedCache[V]) lockShardUncounted(id string) *shard[V] {
+	for {
+		t := c.table.Load()
+		shard := &t.shards[c.shardIndex(id, len(t.shards))]
+		shard.mu.RWMutex.Lock()
+		if !t.retired {
+			return shard
+		}
+		shard.mu.Unlock()
+	}
+}
+
+// ResizeShards rehashes every entry into n new shards (n <= 0 falls back to
+// ShardCount), for a live cache that turned out to be under-sharded. It holds
+// every shard lock while copying and swaps the new table in atomically, so it
+// blocks all access for the duration: expensive, but meant to be rare.
+func (c *GenericShardedCache[V]) ResizeShards(n int) {
+	if n <= 0 {
+		n = ShardCount
+	}
+	c.resizeMu.Lock()
+	defer c.resizeMu.Unlock()
+	old := c.table.Load()
+	for i := range old.shards {
+		old.shards[i].mu.Lock()
+	}
+	t := newShardTable[V](n, c.counted)
+	for i := range old.shards {
+		for id, v := range old.shards[i].data {
+			t.shards[c.shardIndex(id, n)].data[id] = v
+		}
+	}
+	c.table.Store(t)
+	old.retired = true
+	for i := range old.shards {
+		old.shards[i].mu.Unlock()
+	}
+}
+
+func (c *GenericShardedCache[V]) Get(id string) (V, bool) {
+	shard := c.rlockShard(id)
+	defer shard.mu.RUnlock()
+	v, ok := shard.data[id]
+	return v, ok
+}
+
+func (c *GenericShardedCache[V]) Update(id string, v V) {
+	shard := c.lockShard(id)
+	defer shard.mu.Unlock()
+	shard.data[id] = v
+}
+
+func (c *GenericShardedCache[V]) Delete(id string) {
+	shard := c.lockShard(id)
+	defer shard.mu.Unlock()
+	delete(shard.data, id)
+}
+
+// Clear empties each shard in turn; it is not atomic across shards.
+func (c *GenericShardedCache[V]) Clear() {
+	c.resizeMu.RLock()
+	defer c.resizeMu.RUnlock()
+	t := c.table.Load()
+	for i := range t.shards {
+		shard := &t.shards[i]
+		shard.mu.Lock()
+		shard.data = make(map[string]V)
+		shard.mu.Unlock()
+	}
+}
+
+func (c *GenericShardedCache[V]) Len() int {
+	t := c.table.Load()
+	n := 0
+	for i := range t.shards {
+		shard := &t.shards[i]
+		shard.mu.RLock()
+		n += len(shard.data)
+		shard.mu.RUnlock()
+	}
+	return n
+}
+
+// Range calls fn for each entry until fn returns false, holding one shard's
+// read lock at a time. fn must not write to the cache or it may deadlock.
+func (c *GenericShardedCache[V]) Range(fn func(id string, v V) bool) {
+	t := c.table.Load()
+	for i := range t.shards {
+		if !t.shards[i].rangeLocked(fn) {
+			return
+		}
+	}
+}
+
+func (s *shard[V]) rangeLocked(fn func(id string, v V) bool) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for id, v := range s.data {
+		if !fn(id, v) {
+			return false
+		}
+	}
+	return true
+}
+
+// 4. sync.Map Cache
+type GenericSyncMapCache[V any] struct {
+	data      atomic.Pointer[sync.Map]
+	compactMu sync.RWMutex // held shared by writers, exclusively by Compact
+}
+
+func NewGenericSyncMapCache[V any]() *GenericSyncMapCache[V] {
+	c := &GenericSyncMapCache[V]{}
+	c.data.Store(new(sync.Map))
+	return c
+}
+
+// lockMap returns the current map for a write, holding off Compact so the
+// write can't land in a map that is about to be replaced. Callers must
+// call c.compactMu.RUnlock when done.
+func (c *GenericSyncMapCache[V]) lockMap() *sync.Map {
+	c.compactMu.RLock()
+	return c.data.Load()
+}
+
+// Compact copies the live entries into a fresh sync.Map and swaps it in,
+// dropping whatever internal state deletions left behind. Writes wait for the
+// copy, so none are lost; reads carry on against the old map meanwhile. Go
+// 1.24's sync.Map prunes deleted entries itself, so this matters far less
+// than it did with the older read/dirty-map implementation.
+func (c *GenericSyncMapCache[V]) Compact() {
+	c.compactMu.Lock()
+	defer c.compactMu.Unlock()
+	fresh := new(sync.Map)
+	c.data.Load().Range(func(k, v any) bool {
+		fresh.Store(k, v)
+		return true
+	})
+	c.data.Store(fresh)
+}
+
+func (c *GenericSyncMapCache[V]) Get(id string) (V, bool) {
+	v, ok := c.data.Load().Load(id)
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	return v.(V), true
+}
+
+func (c *GenericSyncMapCache[V]) Update(id string, v V) {
+	m := c.lockMap()
+	defer c.compactMu.RUnlock()
+	m.Store(id, v)
+}
+
+func (c *GenericSyncMapCache[V]) Delete(id string) {
+	m := c.lockMap()
+	defer c.compactMu.RUnlock()
+	m.Delete(id)
+}
+
+func (c *GenericSyncMapCache[V]) Clear() {
+	m := c.lockMap()
+	defer c.compactMu.RUnlock()
+	m.Clear()
+}
+
+// Len walks the map, since sync.Map doesn't track its size
+func (c *GenericSyncMapCache[V]) Len() int {
+	n := 0
+	c.data.Load().Range(func(_, _ any) bool {
+		n++
+		return true
+	})
+	return n
+}
+
+// Range has sync.Map.Range semantics: no lock is held, so fn may call back
+// into the cache, but concurrent writes may or may not be observed.
+func (c *GenericSyncMapCache[V]) Range(fn func(id string, v V) bool) {
+	c.data.Load().Range(func(k, v any) bool {
+		return fn(k.(string), v.(V))
+	})
+}
+
+// 5. Spinlock Cache
+type GenericSpinLockCache[V any] struct {
+	lock int32
+	data map[string]V
+	// Backoff: after spins failed attempts, sleep with exponential backoff
+	// capped at maxSleep. maxSleep 0 spins forever.
+	spins    int
+	maxSleep time.Duration
+}
+
+func NewGenericSpinLockCache[V any]() *GenericSpinLockCache[V] {
+	return &GenericSpinLockCache[V]{data: make(map[string]V)}
+}
+
+func (c *GenericSpinLockCache[V]) Get(id string) (V, bool) {
+	c.acquire()
+	v, ok := c.data[id]
+	c.release()
+	return v, ok
+}
+
+func (c *GenericSpinLockCache[V]) Update(id string, v V) {
+	c.acquire()
+	c.data[id] = v
+	c.release()
+}
+
+func (c *GenericSpinLockCache[V]) Delete(id string) {
+	c.acquire()
+	delete(c.data, id)
+	c.release()
+}
+
+func (c *GenericSpinLockCache[V]) Clear() {
+	c.acquire()
+	c.data = make(map[string]V)
+	c.release()
+}
+
+func (c *GenericSpinLockCache[V]) Len() int {
+	c.acquire()
+	n := len(c.data)
+	c.release()
+	return n
+}
+
+// Range calls fn for each entry until fn returns false. The lock is held for
+// the whole walk, so fn must not call back into the cache.
+func (c *GenericSpinLockCache[V]) Range(fn func(id string, v V) bool) {
+	c.acquire()
+	defer c.release()
+	for id, v := range c.data {
+		if !fn(id, v) {
+			return
+		}
+	}
+}
+
+func (c *GenericSpinLockCache[V]) acquire() {
+	// Spin acquire
+	var b spinBackoff
+	for !atomic.CompareAndSwapInt32(&c.lock, 0, 1) {
+		c.wait(&b)
+	}
+}
+
+// spinBackoff tracks one acquisition's failed attempts.
+type spinBackoff struct {
+	spins int
+	sleep time.Duration
+}
+
+// wait backs off after a failed attempt: yield while spins remain, then sleep.
+func (c *GenericSpinLockCache[V]) wait(b *spinBackoff) {
+	if c.maxSleep <= 0 || b.spins < c.spins {
+		b.spins++
+		runtime.Gosched() // Yield CPU to avoid starvation
+		return
+	}
+	b.sleep = min(max(2*b.sleep, time.Microsecond), c.maxSleep)
+	time.Sleep(b.sleep)
+}
+
+func (c *GenericSpinLockCache[V]) release() {
+	atomic.StoreInt32(&c.lock, 0)
+}
+
+// 6. Copy-on-Write Cache
+type GenericCOWCache[V any] struct {
+	mu   sync.Mutex // serializes writers; readers never take it
+	data atomic.Pointer[map[string]V]
+
+	copies        atomic.Uint64
+	copiedEntries atomic.Uint64
+}
+
+func NewGenericCOWCache[V any]() *GenericCOWCache[V] {
+	c := &GenericCOWCache[V]{}
+	m := make(map[string]V)
+	c.data.Store(&m)
+	return c
+}
+
+// load returns the current map. It is never written after being stored, so
+// callers may read it without a lock but must not modify it.
+func (c *GenericCOWCache[V]) load() map[string]V {
+	return *c.data.Load()
+}
+
+func (c *GenericCOWCache[V]) Get(id string) (V, bool) {
+	v, ok := c.load()[id] // Read is completely lock-free!
+	return v, ok
+}
+
+func (c *GenericCOWCache[V]) Update(id string, v V) {
+	// Writers must be serialized, otherwise two of them can copy the same old
+	// map and one write is silently lost when the other Store wins.
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	old := c.load()
+	// Copy entire map (write becomes slow)
+	new := make(map[string]V, len(old)+1)
+	for k, val := range old {
+		new[k] = val
+	}
+	new[id] = v
+	c.data.Store(&new)
+	c.recordCopy(len(old))
+}
+
+func (c *GenericCOWCache[V]) Delete(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	old := c.load()
+	if _, ok := old[id]; !ok {
+		return
+	}
+	// Rebuild the map without the key, then swap it in
+	new := make(map[string]V, len(old))
+	for k, v := range old {
+		if k != id {
+			new[k] = v
+		}
+	}
+	c.data.Store(&new)
+	c.recordCopy(len(old) - 1)
+}
+
+func (c *GenericCOWCache[V]) recordCopy(entries int) {
+	c.copies.Add(1)
+	c.copiedEntries.Add(uint64(entries))
+}
+
+func (c *GenericCOWCache[V]) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	m := make(map[string]V)
+	c.data.Store(&m)
+}
+
+func (c *GenericCOWCache[V]) Len() int {
+	return len(c.load())
+}
+
+// Range walks the map as of the call without any lock, so fn may call back
+// into the cache; its writes aren't observed by the walk.
+func (c *GenericCOWCache[V]) Range(fn func(id string, v V) bool) {
+	for id, v := range c.load() {
+		if !fn(id, v) {
+			return
+		}
+	}
+}
+
+// 7. Hybrid Cache (Sharded + COW)
+type GenericHybridCache[V any] struct {
+	// Hot data: sharded lock protection
+	hot [ShardCount]hybridShard[V]
+	// Cold data: COW (history records, rarely updated)
+	coldMu sync.Mutex // serializes cold writers; cold reads stay lock-free
+	cold   atomic.Pointer[map[string]V]
+	// Hot shards above hotLimit entries demote their oldest entries to cold
+	hotLimit int
+}
+
+type hybridShard[V any] struct {
+	mu   sync.RWMutex
+	data map[string]V
+	// Insertion order, oldest at the front; only tracked with a hotLimit
+	order *list.List
+	elems map[string]*list.Element
+}
+
+func (s *hybridShard[V]) put(id string, v V) {
+	if _, ok := s.data[id]; !ok && s.elems != nil {
+		s.elems[id] = s.order.PushBack(id)
+	}
+	s.data[id] = v
+}
+
+func (s *hybridShard[V]) remove(id string) {
+	delete(s.data, id)
+	if el, ok := s.elems[id]; ok {
+		s.order.Remove(el)
+		delete(s.elems, id)
+	}
+}
+
+func (s *hybridShard[V]) reset() {
+	s.data = make(map[string]V)
+	if s.elems != nil {
+		s.order = list.New()
+		s.elems = make(map[string]*list.Element)
+	}
+}
+
+func NewGenericHybridCache[V any]() *GenericHybridCache[V] {
+	return NewGenericHybridCacheWithLimit[V](0)
+}
+
+// NewGenericHybridCacheWithLimit caps each hot shard at hotShardLimit entries,
+// demoting the oldest inserted entries to the cold tier once a shard grows
+// past it. hotShardLimit <= 0 means no limit.
+func NewGenericHybridCacheWithLimit[V any](hotShardLimit int) *GenericHybridCache[V] {
+	c := &GenericHybridCache[V]{}
+	c.init(hotShardLimit)
+	return c
+}
+
+// init sets up empty tiers, for constructors that build the cache in place.
+func (c *GenericHybridCache[V]) init(hotShardLimit int) {
+	c.hotLimit = hotShardLimit
+	for i := range c.hot {
+		if hotShardLimit > 0 {
+			c.hot[i].elems = make(map[string]*list.Element)
+		}
+		c.hot[i].reset()
+	}
+	m := make(map[string]V)
+	c.cold.Store(&m)
+}
+
+func (c *GenericHybridCache[V]) getShard(id string) int {
+	return int(fnv32a(id) % ShardCount)
+}
+
+// loadCold returns the current cold map, which is never written after being
+// stored.
+func (c *GenericHybridCache[V]) loadCold() map[string]V {
+	return *c.cold.Load()
+}
+
+func (c *GenericHybridCache[V]) Get(id string) (V, bool) {
+	// Try hot cache first
+	shard := &c.hot[c.getShard(id)]
+	shard.mu.RLock()
+	v, ok := shard.data[id]
+	shard.mu.RUnlock()
+	if ok {
+		return v, true
+	}
+	// Fallback to cold cache
+	v, ok = c.loadCold()[id]
+	return v, ok
+}
+
+func (c *GenericHybridCache[V]) Update(id string, v V) {
+	shard := &c.hot[c.getShard(id)]
+	shard.mu.Lock()
+	shard.put(id, v)
+	over := c.hotLimit > 0 && len(shard.data) > c.hotLimit
+	shard.mu.Unlock()
+	if over {
+		c.demoteOverflow(shard)
+	}
+}
+
+// UpdateCold writes v straight to the cold tier. A hot entry for id still
+// shadows it.
+func (c *GenericHybridCache[V]) UpdateCold(id string, v V) {
+	c.coldMu.Lock()
+	defer c.coldMu.Unlock()
+	c.editColdLocked(func(m map[string]V) { m[id] = v })
+}
+
+// editColdLocked publishes a copy of the cold map with edit applied, and
+// returns the map it replaced. The caller must hold coldMu.
+func (c *GenericHybridCache[V]) editColdLocked(edit func(m map[string]V)) map[string]V {
+	old := c.loadCold()
+	new := make(map[string]V, len(old)+1)
+	for k, v := range old {
+		new[k] = v
+	}
+	edit(new)
+	c.cold.Store(&new)
+	return old
+}
+
+// Demote moves id from its hot shard into the cold tier. It is a no-op if id
+// isn't hot.
+func (c *GenericHybridCache[V]) Demote(id string) {
+	// Same lock order as UpdateCold: cold writer first, then the shard
+	c.coldMu.Lock()
+	defer c.coldMu.Unlock()
+	shard := &c.hot[c.getShard(id)]
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	v, ok := shard.data[id]
+	if !ok {
+		return
+	}
+	shard.remove(id)
+	c.editColdLocked(func(m map[string]V) { m[id] = v })
+}
+
+// demoteOverflow moves the oldest entries of shard to cold until it is back
+// within hotLimit. Both locks are held while moving, so an entry is never
+// missing from both tiers.
+func (c *GenericHybridCache[V]) demoteOverflow(shard *hybridShard[V]) {
+	c.coldMu.Lock()
+	defer c.coldMu.Unlock()
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	moved := make(map[string]V)
+	for len(shard.data) > c.hotLimit {
+		id := shard.order.Front().Value.(string)
+		moved[id] = shard.data[id]
+		shard.remove(id)
+	}
+	if len(moved) > 0 {
+		c.editColdLocked(func(m map[string]V) {
+			for id, v := range moved {
+				m[id] = v
+			}
+		})
+	}
+}
+
+// Delete removes id from both tiers so a stale cold entry can't resurface
+// once the hot entry is gone.
+func (c *GenericHybridCache[V]) Delete(id string) {
+	c.coldMu.Lock()
+	if _, ok := c.loadCold()[id]; ok {
+		c.editColdLocked(func(m map[string]V) { delete(m, id) })
+	}
+	c.coldMu.Unlock()
+
+	// Cold first: a racing promotion either lands before this and is removed,
+	// or finds id gone from the cold tier and skips
+	shard := &c.hot[c.getShard(id)]
+	shard.mu.Lock()
+	shard.remove(id)
+	shard.mu.Unlock()
+}
+
+func (c *GenericHybridCache[V]) Clear() {
+	for i := range c.hot {
+		shard := &c.hot[i]
+		shard.mu.Lock()
+		shard.reset()
+		shard.mu.Unlock()
+	}
+
+	c.coldMu.Lock()
+	defer c.coldMu.Unlock()
+	m := make(map[string]V)
+	c.cold.Store(&m)
+}
+
+// Len counts distinct ids across both tiers; a cold entry shadowed by a hot
+// one is only counted once.
+func (c *GenericHybridCache[V]) Len() int {
+	n := 0
+	c.Range(func(string, V) bool {
+		n++
+		return true
+	})
+	return n
+}
+
+// Range calls fn once per id until fn returns false, with the hot value where
+// both tiers hold one. It holds one hot shard's read lock at a time, so fn
+// must not write to the cache or it may deadlock.
+func (c *GenericHybridCache[V]) Range(fn func(id string, v V) bool) {
+	cold := c.loadCold()
+	for i := range c.hot {
+		shard := &c.hot[i]
+		shard.mu.RLock()
+		for id, v := range shard.data {
+			if !fn(id, v) {
+				shard.mu.RUnlock()
+				return
+			}
+		}
+		shard.mu.RUnlock()
+	}
+	for id, v := range cold {
+		shard := &c.hot[c.getShard(id)]
+		shard.mu.RLock()
+		_, shadowed := shard.data[id]
+		shard.mu.RUnlock()
+		if !shadowed && !fn(id, v) {
+			return
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+)
+
+func genericImplementations[V any]() []struct {
+	name string
+	new  func() GenericCache[V]
+} {
+	return []struct {
+		name string
+		new  func() GenericCache[V]
+	}{
+		{"Mutex", func() GenericCache[V] { return NewGenericMutexCache[V]() }},
+		{"RWMutex", func() GenericCache[V] { return NewGenericRWMutexCache[V]() }},
+		{"Sharded", func() GenericCache[V] { return NewGenericShardedCache[V]() }},
+		{"SyncMap", func() GenericCache[V] { return NewGenericSyncMapCache[V]() }},
+		{"SpinLock", func() GenericCache[V] { return NewGenericSpinLockCache[V]() }},
+		{"COW", func() GenericCache[V] { return NewGenericCOWCache[V]() }},
+		{"Hybrid", func() GenericCache[V] { return NewGenericHybridCache[V]() }},
+	}
+}
+
+func TestGenericCacheInt(t *testing.T) {
+	for _, impl := range genericImplementations[int]() {
+		t.Run(impl.name, func(t *testing.T) {
+			c := impl.new()
+			c.Update("zero", 0)
+			c.Update("answer", 42)
+
+			// A stored zero value must still report as present
+			if v, ok := c.Get("zero"); !ok || v != 0 {
+				t.Errorf("expected (0, true), got (%d, %v)", v, ok)
+			}
+			if v, ok := c.Get("answer"); !ok || v != 42 {
+				t.Errorf("expected (42, true), got (%d, %v)", v, ok)
+			}
+			if v, ok := c.Get("missing"); ok {
+				t.Errorf("expected miss, got (%d, %v)", v, ok)
+			}
+		})
+	}
+}
+
+func TestGenericCacheStruct(t *testing.T) {
+	for _, impl := range genericImplementations[DiskStatus]() {
+		t.Run(impl.name, func(t *testing.T) {
+			c := impl.new()
+			c.Update("disk-1", DiskStatus{ID: "disk-1", Health: 100, Temp: 45})
+
+			got, ok := c.Get("disk-1")
+			if !ok || got.ID != "disk-1" || got.Health != 100 || got.Temp != 45 {
+				t.Errorf("expected disk-1, got (%v, %v)", got, ok)
+			}
+			if got, ok := c.Get("disk-2"); ok {
+				t.Errorf("expected miss, got (%v, %v)", got, ok)
+			}
+		})
+	}
+
+	t.Run("HybridCold", func(t *testing.T) {
+		c := NewGenericHybridCache[DiskStatus]()
+		c.UpdateCold("disk-1", DiskStatus{ID: "disk-1", Health: 50})
+		if got, ok := c.Get("disk-1"); !ok || got.Health != 50 {
+			t.Errorf("expected cold disk-1, got (%v, %v)", got, ok)
+		}
+	})
+}
+
+// genericStore is the storage API every generic cache offers on top of
+// GenericCache, which the *DiskStatus wrappers delegate to.
+type genericStore[V any] interface {
+	GenericCache[V]
+	Delete(id string)
+	Clear()
+	Len() int
+	Range(fn func(id string, v V) bool)
+}
+
+func TestGenericCacheStore(t *testing.T) {
+	for _, impl := range genericImplementations[int]() {
+		t.Run(impl.name, func(t *testing.T) {
+			c, ok := impl.new().(genericStore[int])
+			if !ok {
+				t.Fatalf("expected Delete, Clear, Len and Range")
+			}
+			for i := 0; i < 10; i++ {
+				c.Update(fmt.Sprintf("disk-%d", i), i)
+			}
+			c.Delete("disk-0")
+			c.Delete("missing")
+			if got := c.Len(); got != 9 {
+				t.Errorf("expected Len 9, got %d", got)
+			}
+			sum := 0
+			c.Range(func(_ string, v int) bool {
+				sum += v
+				return true
+			})
+			if sum != 45 {
+				t.Errorf("expected Range to visit 1..9, got sum %d", sum)
+			}
+			visited := 0
+			c.Range(func(string, int) bool {
+				visited++
+				return false
+			})
+			if visited != 1 {
+				t.Errorf("expected Range to stop after fn returns false, visited %d", visited)
+			}
+			c.Clear()
+			if _, ok := c.Get("disk-1"); ok || c.Len() != 0 {
+				t.Errorf("expected Clear to empty the cache")
+			}
+		})
+	}
+
+	t.Run("HybridShadowing", func(t *testing.T) {
+		c := NewGenericHybridCache[int]()
+		c.UpdateCold("disk-1", 1)
+		c.Update("disk-1", 2)
+		if got := c.Len(); got != 1 {
+			t.Errorf("expected a shadowed cold entry counted once, got %d", got)
+		}
+		c.Range(func(_ string, v int) bool {
+			if v != 2 {
+				t.Errorf("expected the hot value, got %d", v)
+			}
+			return true
+		})
+		c.Delete("disk-1")
+		if _, ok := c.Get("disk-1"); ok {
+			t.Errorf("expected Delete to remove both tiers")
+		}
+	})
+
+	t.Run("ShardedResize", func(t *testing.T) {
+		c := NewGenericShardedCacheWithShards[int](2)
+		for i := 0; i < 100; i++ {
+			c.Update(fmt.Sprintf("disk-%d", i), i)
+		}
+		c.ResizeShards(16)
+		for i := 0; i < 100; i++ {
+			if v, ok := c.Get(fmt.Sprintf("disk-%d", i)); !ok || v != i {
+				t.Fatalf("expected disk-%d to survive the resize, got (%d, %v)", i, v, ok)
+			}
+		}
+	})
+}
@@ -0,0 +1,59 @@
+package cache
+
+import (
+	"fmt"
+	"sync"
+)
+
+// GuardedCache is a debugging wrapper that catches callers mutating a
+// *DiskStatus returned by Get, which corrupts the shared value every other
+// reader sees. Get remembers a copy of each value it hands out, and the next
+// Get or Update of that key panics if the pointed-to struct no longer matches.
+// It adds a lock and a map write to every Get, so use it in tests only.
+type GuardedCache struct {
+	cache Cache
+
+	mu     sync.Mutex
+	issued map[string]issued
+}
+
+// issued is a pointer handed out by Get and the value it held at the time.
+type issued struct {
+	status *DiskStatus
+	want   DiskStatus
+}
+
+var _ Cache = (*GuardedCache)(nil)
+
+func NewGuardedCache(c Cache) *GuardedCache {
+	return &GuardedCache{cache: c, issued: make(map[string]issued)}
+}
+
+func (c *GuardedCache) Get(id string) *DiskStatus {
+	status := c.cache.Get(id)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checkLocked(id)
+	if status != nil {
+		c.issued[id] = issued{status: status, want: *status}
+	}
+	return status
+}
+
+func (c *GuardedCache) Update(id string, status *DiskStatus) {
+	c.mu.Lock()
+	c.checkLocked(id)
+	delete(c.issued, id)
+	c.mu.Unlock()
+	c.cache.Update(id, status)
+}
+
+// checkLocked panics if the value last returned for id was modified since.
+// The caller must hold c.mu.
+func (c *GuardedCache) checkLocked(id string) {
+	is, ok := c.issued[id]
+	if !ok || *is.status == is.want {
+		return
+	}
+	panic(fmt.Sprintf("cache: value for %q was mutated after Get: returned %+v, now %+v", id, is.want, *is.status))
+}
@@ -0,0 +1,33 @@
+package cache
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGuardedCache(t *testing.T) {
+	t.Run("CleanUse", func(t *testing.T) {
+		c := NewGuardedCache(NewMutexCache())
+		c.Update("disk-1", &DiskStatus{ID: "disk-1", Temp: 30})
+		c.Get("disk-1")
+		c.Update("disk-1", &DiskStatus{ID: "disk-1", Temp: 31})
+		if got := c.Get("disk-1"); got.Temp != 31 {
+			t.Errorf("expected Temp 31, got %d", got.Temp)
+		}
+	})
+
+	t.Run("MutationPanics", func(t *testing.T) {
+		c := NewGuardedCache(NewMutexCache())
+		c.Update("disk-1", &DiskStatus{ID: "disk-1", Temp: 30})
+		c.Get("disk-1").Temp = 99
+
+		defer func() {
+			msg, _ := recover().(string)
+			if !strings.Contains(msg, `"disk-1" was mutated`) {
+				t.Errorf("expected a mutation panic, got %q", msg)
+			}
+		}()
+		c.Get("disk-1")
+		t.Errorf("expected Get to panic")
+	})
+}
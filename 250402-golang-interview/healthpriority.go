@@ -0,0 +1,117 @@
+package cache
+
+import (
+	"container/heap"
+	"sync"
+)
+
+// HealthPriorityCache is a bounded cache that, when full, evicts the
+// healthiest disk, so the problem disks operators want to inspect stay
+// resident. Entries sit in a heap with the highest Health at the root, making
+// eviction O(log n).
+type HealthPriorityCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	items      map[string]*healthEntry
+	heap       healthHeap
+}
+
+type healthEntry struct {
+	id     string
+	status *DiskStatus
+	health int // status.Health, kept next to index for the heap
+	index  int // position in the heap, maintained by healthHeap
+}
+
+var _ Cache = (*HealthPriorityCache)(nil)
+
+// NewHealthPriorityCache returns a cache holding at most maxEntries entries.
+// maxEntries <= 0 means no limit.
+func NewHealthPriorityCache(maxEntries int) *HealthPriorityCache {
+	return &HealthPriorityCache{
+		maxEntries: maxEntries,
+		items:      make(map[string]*healthEntry),
+	}
+}
+
+func (c *HealthPriorityCache) Get(id string) *DiskStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[id]; ok {
+		return e.status
+	}
+	return nil
+}
+
+// Update stores status, then evicts the healthiest entry if the cache is over
+// its bound. The new entry itself is evicted if it is the healthiest.
+func (c *HealthPriorityCache) Update(id string, status *DiskStatus) {
+	if status == nil {
+		c.Delete(id)
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	health := status.Health
+	if e, ok := c.items[id]; ok {
+		e.status, e.health = status, health
+		heap.Fix(&c.heap, e.index)
+		return
+	}
+	e := &healthEntry{id: id, status: status, health: health}
+	c.items[id] = e
+	heap.Push(&c.heap, e)
+	if c.maxEntries > 0 && len(c.items) > c.maxEntries {
+		victim := heap.Pop(&c.heap).(*healthEntry)
+		delete(c.items, victim.id)
+	}
+}
+
+// Contains reports presence without counting as an access.
+func (c *HealthPriorityCache) Contains(id string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.items[id]
+	return ok
+}
+
+func (c *HealthPriorityCache) Delete(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[id]; ok {
+		heap.Remove(&c.heap, e.index)
+		delete(c.items, id)
+	}
+}
+
+func (c *HealthPriorityCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.items)
+}
+
+// healthHeap implements heap.Interface with the highest health at the root.
+type healthHeap []*healthEntry
+
+func (h healthHeap) Len() int           { return len(h) }
+func (h healthHeap) Less(i, j int) bool { return h[i].health > h[j].health }
+
+func (h healthHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *healthHeap) Push(x any) {
+	e := x.(*healthEntry)
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *healthHeap) Pop() any {
+	old := *h
+	e := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return e
+}
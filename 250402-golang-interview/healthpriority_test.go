@@ -0,0 +1,53 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestHealthPriorityCache(t *testing.T) {
+	t.Run("HealthiestEvicted", func(t *testing.T) {
+		const maxEntries = 4
+		c := NewHealthPriorityCache(maxEntries)
+		healths := []int{50, 95, 10, 80, 30, 100, 70}
+		for i, h := range healths {
+			id := fmt.Sprintf("disk-%d", i)
+			c.Update(id, &DiskStatus{ID: id, Health: h})
+		}
+
+		// The three healthiest (100, 95, 80) are dropped
+		for i, h := range healths {
+			id := fmt.Sprintf("disk-%d", i)
+			if want := h < 80; c.Contains(id) != want {
+				t.Errorf("%s (Health %d): expected present %v", id, h, want)
+			}
+		}
+		if got := c.Len(); got != maxEntries {
+			t.Errorf("expected Len %d, got %d", maxEntries, got)
+		}
+	})
+
+	t.Run("OverwriteReorders", func(t *testing.T) {
+		c := NewHealthPriorityCache(2)
+		c.Update("disk-a", &DiskStatus{ID: "disk-a", Health: 90})
+		c.Update("disk-b", &DiskStatus{ID: "disk-b", Health: 20})
+		// disk-a degrades, so disk-b is now the healthiest
+		c.Update("disk-a", &DiskStatus{ID: "disk-a", Health: 5})
+		c.Update("disk-b", &DiskStatus{ID: "disk-b", Health: 60})
+		c.Update("disk-c", &DiskStatus{ID: "disk-c", Health: 40})
+		if c.Contains("disk-b") || !c.Contains("disk-a") || !c.Contains("disk-c") {
+			t.Errorf("expected disk-b evicted after its Health rose above the others")
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		c := NewHealthPriorityCache(2)
+		c.Update("disk-a", &DiskStatus{ID: "disk-a", Health: 90})
+		c.Update("disk-b", &DiskStatus{ID: "disk-b", Health: 20})
+		c.Delete("disk-a")
+		c.Update("disk-c", &DiskStatus{ID: "disk-c", Health: 50})
+		if c.Len() != 2 || !c.Contains("disk-b") || !c.Contains("disk-c") {
+			t.Errorf("expected disk-b and disk-c after deleting disk-a")
+		}
+	})
+}
@@ -0,0 +1,44 @@
+package cache
+
+import "sync"
+
+// CacheIterator walks a ShardedCache pull-style. The key set is fixed when
+// the iterator is created, but each value is looked up as Next reaches it, so
+// no lock is held between calls: keys deleted in the meantime are skipped,
+// keys added are not visited, and values reflect the latest Update. Next is
+// safe to call from several goroutines; each key goes to one of them.
+type CacheIterator struct {
+	cache *ShardedCache
+
+	mu   sync.Mutex
+	keys []string
+	pos  int
+}
+
+// NewIterator snapshots the current keys. Reading values through it doesn't
+// count towards Stats.
+func (c *ShardedCache) NewIterator() *CacheIterator {
+	return &CacheIterator{cache: c, keys: c.Keys()}
+}
+
+// Next returns the next entry still present, or ok false once the keys are
+// exhausted.
+func (it *CacheIterator) Next() (id string, status *DiskStatus, ok bool) {
+	for {
+		it.mu.Lock()
+		if it.pos >= len(it.keys) {
+			it.mu.Unlock()
+			return "", nil, false
+		}
+		id = it.keys[it.pos]
+		it.pos++
+		it.mu.Unlock()
+
+		shard := it.cache.base.rlockShard(id)
+		status, ok = shard.data[id]
+		shard.mu.RUnlock()
+		if ok {
+			return id, status, true
+		}
+	}
+}
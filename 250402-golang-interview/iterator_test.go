@@ -0,0 +1,49 @@
+package cache
+
+import "testing"
+
+func TestCacheIterator(t *testing.T) {
+	t.Run("VisitsAll", func(t *testing.T) {
+		c := NewShardedCache()
+		initCache(c)
+		it := c.NewIterator()
+		got := make(map[string]*DiskStatus)
+		for id, status, ok := it.Next(); ok; id, status, ok = it.Next() {
+			if _, dup := got[id]; dup {
+				t.Fatalf("expected %s once, got it twice", id)
+			}
+			got[id] = status
+		}
+		if len(got) != numKeys {
+			t.Fatalf("expected %d entries, got %d", numKeys, len(got))
+		}
+		for id, status := range got {
+			if status.ID != id {
+				t.Errorf("expected status for %s, got %v", id, status)
+			}
+		}
+		if _, _, ok := it.Next(); ok {
+			t.Errorf("expected an exhausted iterator to stay exhausted")
+		}
+	})
+
+	t.Run("SkipsDeleted", func(t *testing.T) {
+		c := NewShardedCache()
+		for _, id := range []string{"disk-1", "disk-2", "disk-3"} {
+			c.Update(id, &DiskStatus{ID: id})
+		}
+		it := c.NewIterator()
+		first, _, _ := it.Next()
+		// Delete the next key due
+		deleted := it.keys[it.pos]
+		c.Delete(deleted)
+
+		seen := map[string]bool{first: true}
+		for id, _, ok := it.Next(); ok; id, _, ok = it.Next() {
+			seen[id] = true
+		}
+		if seen[deleted] || len(seen) != 2 {
+			t.Errorf("expected %s skipped and 2 entries visited, got %v", deleted, seen)
+		}
+	})
+}
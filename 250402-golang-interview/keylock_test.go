@@ -0,0 +1,128 @@
+package cache
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestKeyLockCache(t *testing.T) {
+	t.Run("ConcurrentUpdateFunc", func(t *testing.T) {
+		const goroutines, perGoroutine, keys = 32, 200, 8
+		c := NewKeyLockCache()
+		incrTemp := func(s *DiskStatus) *DiskStatus {
+			if s == nil {
+				return &DiskStatus{Temp: 1}
+			}
+			return &DiskStatus{ID: s.ID, Temp: s.Temp + 1}
+		}
+
+		var wg sync.WaitGroup
+		for g := 0; g < goroutines; g++ {
+			wg.Add(1)
+			go func(g int) {
+				defer wg.Done()
+				for i := 0; i < perGoroutine; i++ {
+					id := fmt.Sprintf("disk-%d", (g+i)%keys)
+					c.UpdateFunc(id, incrTemp)
+					c.Get(id)
+				}
+			}(g)
+		}
+		wg.Wait()
+
+		total := 0
+		for k := 0; k < keys; k++ {
+			total += c.Get(fmt.Sprintf("disk-%d", k)).Temp
+		}
+		if total != goroutines*perGoroutine {
+			t.Errorf("expected %d increments, got %d", goroutines*perGoroutine, total)
+		}
+		if got := c.Len(); got != keys {
+			t.Errorf("expected Len %d, got %d", keys, got)
+		}
+	})
+
+	t.Run("OtherKeysDontWait", func(t *testing.T) {
+		c := NewKeyLockCache()
+		entered, release := make(chan struct{}), make(chan struct{})
+		go c.UpdateFunc("disk-1", func(*DiskStatus) *DiskStatus {
+			close(entered)
+			<-release
+			return &DiskStatus{ID: "disk-1"}
+		})
+		<-entered
+
+		done := make(chan struct{})
+		go func() {
+			c.Update("disk-2", &DiskStatus{ID: "disk-2"})
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("expected Update(disk-2) not to wait for disk-1's lock")
+		}
+		close(release)
+	})
+
+	t.Run("ReclaimsLocks", func(t *testing.T) {
+		c := NewKeyLockCache()
+		var wg sync.WaitGroup
+		for g := 0; g < 8; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 100; i++ {
+					id := fmt.Sprintf("disk-%d", i)
+					c.Update(id, &DiskStatus{ID: id})
+					c.Delete(id)
+					c.Get(id)
+				}
+			}()
+		}
+		wg.Wait()
+
+		left := 0
+		for i := range c.shards {
+			s := &c.shards[i]
+			s.mu.Lock()
+			left += len(s.items)
+			s.mu.Unlock()
+		}
+		if left != 0 || c.Len() != 0 {
+			t.Errorf("expected no entries left, got %d locks and Len %d", left, c.Len())
+		}
+	})
+}
+
+// Benchmark: parallel writes where every goroutine owns its keys. MutexCache
+// serializes them all; KeyLockCache only serializes lookups in the same shard.
+func BenchmarkKeyLockWrite(b *testing.B) {
+	caches := []struct {
+		name string
+		new  func() Cache
+	}{
+		{"Mutex", func() Cache { return NewMutexCache() }},
+		{"KeyLock", func() Cache { return NewKeyLockCache() }},
+	}
+	for _, impl := range caches {
+		b.Run(impl.name, func(b *testing.B) {
+			c := initCache(impl.new())
+			var worker atomic.Int64
+			b.ResetTimer()
+			b.SetParallelism(benchParallel)
+			b.RunParallel(func(pb *testing.PB) {
+				w := int(worker.Add(1))
+				i := 0
+				for pb.Next() {
+					id := fmt.Sprintf("disk-%d", (w*numKeys+i)%(numKeys*benchParallel))
+					c.Update(id, &DiskStatus{ID: id, Health: 100, Temp: 45})
+					i = (i + 1) % numKeys
+				}
+			})
+		})
+	}
+}
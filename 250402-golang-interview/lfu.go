@@ -0,0 +1,187 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+)
+
+// 9. LFU Cache (bounded)
+//
+// Entries live in one list per access frequency, most recently used at the
+// front, and minFreq tracks the lowest non-empty bucket. Eviction takes the
+// back of that bucket, so it is O(1) and ties break by least recently used.
+type LFUCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	items      map[string]*list.Element
+	onEvict    evictions
+	freqs      map[int]*list.List
+	minFreq    int
+}
+
+type lfuEntry struct {
+	id     string
+	status *DiskStatus
+	freq   int
+}
+
+var _ Cache = (*LFUCache)(nil)
+
+// NewLFUCache returns a cache holding at most maxEntries entries. maxEntries
+// <= 0 means no limit.
+func NewLFUCache(maxEntries int) *LFUCache {
+	return &LFUCache{
+		maxEntries: maxEntries,
+		items:      make(map[string]*list.Element),
+		freqs:      make(map[int]*list.List),
+	}
+}
+
+func (c *LFUCache) Get(id string) *DiskStatus {
+	c.mu.Lock()
+	defer c.unlock()
+	el, ok := c.items[id]
+	if !ok {
+		return nil
+	}
+	el = c.touch(el)
+	return el.Value.(*lfuEntry).status
+}
+
+// Update stores status, evicting the least frequently used entry if the cache
+// is full. Overwriting an existing key counts as an access.
+func (c *LFUCache) Update(id string, status *DiskStatus) {
+	if status == nil {
+		c.Delete(id)
+		return
+	}
+	c.mu.Lock()
+	defer c.unlock()
+	if el, ok := c.items[id]; ok {
+		el = c.touch(el)
+		el.Value.(*lfuEntry).status = status
+		return
+	}
+	if c.maxEntries > 0 && len(c.items) >= c.maxEntries {
+		c.removeElement(c.freqs[c.minFreq].Back())
+	}
+	c.items[id] = c.bucket(1).PushFront(&lfuEntry{id: id, status: status, freq: 1})
+	c.minFreq = 1
+}
+
+// OnEvict registers fn to be called after an entry leaves the cache through
+// eviction or Delete. fn runs outside the lock, so it may call back into the
+// cache.
+func (c *LFUCache) OnEvict(fn func(id string, status *DiskStatus)) {
+	c.mu.Lock()
+	defer c.unlock()
+	c.onEvict.fn = fn
+}
+
+// unlock releases c.mu, then reports entries removed while it was held.
+func (c *LFUCache) unlock() {
+	fn, removed := c.onEvict.take()
+	c.mu.Unlock()
+	notify(fn, removed)
+}
+
+// Peek is Get without counting as an access, so monitoring can read entries
+// without changing which one is evicted next.
+func (c *LFUCache) Peek(id string) *DiskStatus {
+	c.mu.Lock()
+	defer c.unlock()
+	el, ok := c.items[id]
+	if !ok {
+		return nil
+	}
+	return el.Value.(*lfuEntry).status
+}
+
+// Contains reports presence without counting as an access.
+func (c *LFUCache) Contains(id string) bool {
+	c.mu.Lock()
+	defer c.unlock()
+	_, ok := c.items[id]
+	return ok
+}
+
+// Snapshot copies all entries without counting as accesses.
+func (c *LFUCache) Snapshot() map[string]*DiskStatus {
+	c.mu.Lock()
+	defer c.unlock()
+	snap := make(map[string]*DiskStatus, len(c.items))
+	for id, el := range c.items {
+		snap[id] = cloneStatus(el.Value.(*lfuEntry).status)
+	}
+	return snap
+}
+
+func (c *LFUCache) Delete(id string) {
+	c.mu.Lock()
+	defer c.unlock()
+	el, ok := c.items[id]
+	if !ok {
+		return
+	}
+	c.removeElement(el)
+	if len(c.items) > 0 && c.freqs[c.minFreq] == nil {
+		c.minFreq = c.lowestFreq()
+	}
+}
+
+func (c *LFUCache) Len() int {
+	c.mu.Lock()
+	defer c.unlock()
+	return len(c.items)
+}
+
+// touch moves el into the next frequency bucket and returns its new element.
+func (c *LFUCache) touch(el *list.Element) *list.Element {
+	e := el.Value.(*lfuEntry)
+	c.unlink(el)
+	if e.freq == c.minFreq && c.freqs[e.freq] == nil {
+		c.minFreq++
+	}
+	e.freq++
+	el = c.bucket(e.freq).PushFront(e)
+	c.items[e.id] = el
+	return el
+}
+
+func (c *LFUCache) removeElement(el *list.Element) {
+	e := el.Value.(*lfuEntry)
+	c.unlink(el)
+	delete(c.items, e.id)
+	c.onEvict.add(e.id, e.status)
+}
+
+// unlink removes el from its bucket, dropping the bucket once it's empty.
+func (c *LFUCache) unlink(el *list.Element) {
+	freq := el.Value.(*lfuEntry).freq
+	l := c.freqs[freq]
+	l.Remove(el)
+	if l.Len() == 0 {
+		delete(c.freqs, freq)
+	}
+}
+
+func (c *LFUCache) bucket(freq int) *list.List {
+	l, ok := c.freqs[freq]
+	if !ok {
+		l = list.New()
+		c.freqs[freq] = l
+	}
+	return l
+}
+
+// lowestFreq scans the buckets. It's only needed when Delete empties the
+// minimum bucket; eviction is always followed by an insert at frequency 1.
+func (c *LFUCache) lowestFreq() int {
+	lowest := 0
+	for freq := range c.freqs {
+		if lowest == 0 || freq < lowest {
+			lowest = freq
+		}
+	}
+	return lowest
+}
@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestLFUCacheEviction(t *testing.T) {
+	const maxEntries = 3
+
+	t.Run("RareKeyEvictedFirst", func(t *testing.T) {
+		c := NewLFUCache(maxEntries)
+		c.Update("hot", &DiskStatus{ID: "hot"})
+		c.Update("rare", &DiskStatus{ID: "rare"})
+		c.Update("warm", &DiskStatus{ID: "warm"})
+		for i := 0; i < 100; i++ {
+			c.Get("hot")
+		}
+		c.Get("warm")
+
+		c.Update("new", &DiskStatus{ID: "new"})
+		if got := c.Get("rare"); got != nil {
+			t.Errorf("expected rare to be evicted, got %v", got)
+		}
+		for _, id := range []string{"hot", "warm", "new"} {
+			if got := c.Get(id); got == nil {
+				t.Errorf("expected %s to survive", id)
+			}
+		}
+	})
+
+	t.Run("TiesBreakByRecency", func(t *testing.T) {
+		c := NewLFUCache(maxEntries)
+		for i := 0; i < maxEntries; i++ {
+			id := fmt.Sprintf("disk-%d", i)
+			c.Update(id, &DiskStatus{ID: id})
+		}
+		// All at frequency 1; disk-0 is the least recently used
+		c.Update("disk-3", &DiskStatus{ID: "disk-3"})
+		if got := c.Get("disk-0"); got != nil {
+			t.Errorf("expected disk-0 to be evicted, got %v", got)
+		}
+		if got := c.Len(); got != maxEntries {
+			t.Errorf("expected Len %d, got %d", maxEntries, got)
+		}
+	})
+
+	t.Run("NewEntryEvictedBeforeFrequentOnes", func(t *testing.T) {
+		c := NewLFUCache(2)
+		c.Update("a", &DiskStatus{ID: "a"})
+		c.Update("b", &DiskStatus{ID: "b"})
+		c.Get("a")
+		c.Get("b")
+		c.Update("c", &DiskStatus{ID: "c"}) // evicts a (freq 2, older than b)
+		c.Update("d", &DiskStatus{ID: "d"}) // evicts c (freq 1)
+		if c.Get("c") != nil || c.Get("a") != nil {
+			t.Errorf("expected a and c to be evicted")
+		}
+		if c.Get("b") == nil || c.Get("d") == nil {
+			t.Errorf("expected b and d to survive")
+		}
+	})
+
+	t.Run("PeekKeepsFrequency", func(t *testing.T) {
+		c := NewLFUCache(2)
+		c.Update("a", &DiskStatus{ID: "a"})
+		c.Update("b", &DiskStatus{ID: "b"})
+		c.Get("b")
+		for i := 0; i < 5; i++ {
+			if got := c.Peek("a"); got == nil {
+				t.Fatalf("expected to peek a")
+			}
+		}
+		c.Update("c", &DiskStatus{ID: "c"})
+		if c.Contains("a") || !c.Contains("b") {
+			t.Errorf("expected peeked a to still be the least frequently used")
+		}
+	})
+
+	t.Run("DeleteMinimumBucket", func(t *testing.T) {
+		c := NewLFUCache(2)
+		c.Update("a", &DiskStatus{ID: "a"})
+		c.Update("b", &DiskStatus{ID: "b"})
+		c.Get("b")
+		c.Delete("a") // empties the frequency-1 bucket
+		c.Update("c", &DiskStatus{ID: "c"})
+		c.Update("d", &DiskStatus{ID: "d"}) // must evict c, not panic
+		if c.Get("c") != nil || c.Get("b") == nil || c.Get("d") == nil {
+			t.Errorf("unexpected contents after delete and eviction")
+		}
+	})
+}
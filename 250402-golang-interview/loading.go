@@ -0,0 +1,420 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+)
+
+// LoadingCache is a read-through wrapper around any Cache: a miss calls
+// loader, stores the result and returns it. Concurrent misses for the same
+// key are coalesced into a single loader call (singleflight-style).
+type LoadingCache struct {
+	cache        Cache
+	loader       func(id string) (*DiskStatus, error)
+	negativeTTL  time.Duration
+	refreshAfter time.Duration
+	now          func() time.Time
+
+	mu       sync.Mutex
+	inflight map[string]*loadCall
+	negative map[string]time.Time // id -> when its "not found" result expires
+	loadedAt map[string]time.Time // id -> when it was last stored, for refresh-ahead
+	pruned   int                  // len(negative)+len(loadedAt) after the last prune
+	breaker  breaker
+	stats    LoaderStats
+
+	background sync.WaitGroup // refreshes and timed-out loads, waited for by Close
+	closed     bool
+}
+
+// LoadingOption configures a LoadingCache.
+type LoadingOption func(*LoadingCache)
+
+// WithNegativeTTL remembers "not found" results (loader returning nil, nil)
+// for ttl, so repeated misses don't hit the backend. Errors are never cached.
+func WithNegativeTTL(ttl time.Duration) LoadingOption {
+	return func(c *LoadingCache) {
+		c.negativeTTL = ttl
+	}
+}
+
+// WithRefreshAfter reloads an entry in the background once it is older than
+// d: the Get that notices returns the current value immediately, and at most
+// one refresh per key runs at a time. A failed refresh keeps the old value and
+// is retried by the next Get; a refresh that finds nothing deletes the entry.
+func WithRefreshAfter(d time.Duration) LoadingOption {
+	return func(c *LoadingCache) {
+		c.refreshAfter = d
+	}
+}
+
+// WithCircuitBreaker stops calling a failing loader: after threshold
+// consecutive errors, none more than window apart, the circuit opens and
+// loads fail fast with ErrCircuitOpen for cooldown. Then a single trial load
+// is let through (half-open); success closes the circuit, failure reopens it.
+// Cached values keep being served throughout.
+func WithCircuitBreaker(threshold int, window, cooldown time.Duration) LoadingOption {
+	return func(c *LoadingCache) {
+		c.breaker = breaker{threshold: threshold, window: window, cooldown: cooldown}
+	}
+}
+
+// ErrLoadTimeout is returned by GetWithLoaderTimeout when the loader doesn't
+// finish in time.
+var ErrLoadTimeout = errors.New("cache: load timed out")
+
+// ErrClosed is returned by GetWithLoaderTimeout for a miss it would have to
+// load after Close, since the load could outlive its timeout.
+var ErrClosed = errors.New("cache: loading cache closed")
+
+// ErrCircuitOpen is returned, wrapped with the loader's last error, for loads
+// rejected by an open circuit breaker.
+var ErrCircuitOpen = errors.New("cache: loader circuit open")
+
+// BreakerState is the state of a LoadingCache's circuit breaker.
+type BreakerState int
+
+const (
+	BreakerClosed   BreakerState = iota // loads go through
+	BreakerOpen                         // loads fail fast
+	BreakerHalfOpen                     // one trial load is in flight
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("BreakerState(%d)", int(s))
+}
+
+// LoaderStats describes the loader's health as seen by a LoadingCache.
+type LoaderStats struct {
+	State             BreakerState
+	Loads             uint64 // loader calls
+	Errors            uint64 // loader calls that failed
+	Rejected          uint64 // loads failed fast by the breaker
+	ConsecutiveErrors int
+}
+
+// breaker is the circuit breaker state, guarded by LoadingCache.mu. A zero
+// threshold disables it.
+type breaker struct {
+	threshold        int
+	window, cooldown time.Duration
+
+	state      BreakerState
+	failures   int       // consecutive errors so far
+	lastFailed time.Time // when the latest of them happened
+	openedAt   time.Time
+	lastErr    error
+}
+
+// LoaderStats returns the breaker state and loader counters.
+func (c *LoadingCache) LoaderStats() LoaderStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := c.stats
+	s.State = c.breaker.state
+	if s.State == BreakerOpen && !c.now().Before(c.breaker.openedAt.Add(c.breaker.cooldown)) {
+		// The next load will be the half-open trial
+		s.State = BreakerHalfOpen
+	}
+	s.ConsecutiveErrors = c.breaker.failures
+	return s
+}
+
+// allow reports whether a load may call the loader, moving an open breaker
+// to half-open once its cooldown is over. The caller must hold c.mu.
+func (c *LoadingCache) allow() error {
+	b := &c.breaker
+	switch {
+	case b.threshold <= 0 || b.state == BreakerClosed:
+	case b.state == BreakerOpen && !c.now().Before(b.openedAt.Add(b.cooldown)):
+		b.state = BreakerHalfOpen
+	default:
+		// Open, or half-open with the trial still running
+		c.stats.Rejected++
+		return fmt.Errorf("%w: %w", ErrCircuitOpen, b.lastErr)
+	}
+	c.stats.Loads++
+	return nil
+}
+
+// recordLoad feeds a loader result to the breaker. The caller must hold c.mu.
+func (c *LoadingCache) recordLoad(err error) {
+	b := &c.breaker
+	if err == nil {
+		b.state, b.failures = BreakerClosed, 0
+		return
+	}
+	c.stats.Errors++
+	now := c.now()
+	if b.failures > 0 && now.Sub(b.lastFailed) > b.window {
+		b.failures = 0
+	}
+	b.failures++
+	b.lastFailed, b.lastErr = now, err
+	if b.threshold > 0 && (b.state == BreakerHalfOpen || b.failures >= b.threshold) {
+		b.state, b.openedAt = BreakerOpen, now
+	}
+}
+
+// loadCall is a loader invocation that other callers can wait on.
+type loadCall struct {
+	done   chan struct{}
+	status *DiskStatus
+	err    error
+	stale  bool // id was written during the load; guarded by LoadingCache.mu
+}
+
+func NewLoadingCache(c Cache, loader func(id string) (*DiskStatus, error), opts ...LoadingOption) *LoadingCache {
+	lc := &LoadingCache{
+		cache:    c,
+		loader:   loader,
+		now:      time.Now,
+		inflight: make(map[string]*loadCall),
+		negative: make(map[string]time.Time),
+		loadedAt: make(map[string]time.Time),
+	}
+	for _, opt := range opts {
+		opt(lc)
+	}
+	return lc
+}
+
+// Get returns the cached value, loading it on a miss. Loader errors are
+// returned and nothing is stored; a nil result is returned as-is.
+func (c *LoadingCache) Get(id string) (*DiskStatus, error) {
+	if status := c.cache.Get(id); status != nil {
+		if c.refreshAfter > 0 {
+			c.maybeRefresh(id)
+		}
+		return status, nil
+	}
+	return c.load(id)
+}
+
+// GetWithLoaderTimeout is Get, but gives up waiting for the loader after
+// timeout and returns ErrLoadTimeout. The load keeps running in the background
+// and stores its result for later callers; concurrent misses still share it.
+// After Close, a miss that would start a load fails with ErrClosed.
+func (c *LoadingCache) GetWithLoaderTimeout(id string, timeout time.Duration) (*DiskStatus, error) {
+	if status := c.cache.Get(id); status != nil {
+		if c.refreshAfter > 0 {
+			c.maybeRefresh(id)
+		}
+		return status, nil
+	}
+	call, owner, status := c.join(id)
+	if call == nil {
+		return status, nil
+	}
+	if owner {
+		c.detach(id, call)
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-call.done:
+		return call.status, call.err
+	case <-timer.C:
+		return nil, ErrLoadTimeout
+	}
+}
+
+// Update writes straight through to the underlying cache, replacing any
+// cached "not found" result. A nil status deletes id, so the next Get loads it.
+// A load of id already running is detached: its result still reaches the
+// callers waiting on it but isn't stored over this write, and later misses
+// start a new load.
+func (c *LoadingCache) Update(id string, status *DiskStatus) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cache.Update(id, status)
+	if call, ok := c.inflight[id]; ok {
+		call.stale = true
+		delete(c.inflight, id)
+	}
+	delete(c.negative, id)
+	if status == nil {
+		delete(c.loadedAt, id)
+	} else if c.refreshAfter > 0 {
+		c.loadedAt[id] = c.now()
+		c.prune()
+	}
+}
+
+// minPrune is how many negative and loadedAt entries LoadingCache lets build
+// up before its first prune.
+const minPrune = 64
+
+// prune drops expired "not found" results, and load times of ids the
+// underlying cache no longer holds (evicted or expired there), once the two
+// maps have doubled since the last prune. That keeps them proportional to the
+// live entries at an amortized constant cost per insert. The caller must hold
+// c.mu.
+func (c *LoadingCache) prune() {
+	if len(c.negative)+len(c.loadedAt) < max(2*c.pruned, minPrune) {
+		return
+	}
+	now := c.now()
+	maps.DeleteFunc(c.negative, func(_ string, expires time.Time) bool {
+		return !now.Before(expires)
+	})
+	maps.DeleteFunc(c.loadedAt, func(id string, _ time.Time) bool {
+		return !c.contains(id)
+	})
+	c.pruned = len(c.negative) + len(c.loadedAt)
+}
+
+// contains reports whether the underlying cache holds id, without counting a
+// Get where the cache can tell.
+func (c *LoadingCache) contains(id string) bool {
+	if cc, ok := c.cache.(interface{ Contains(id string) bool }); ok {
+		return cc.Contains(id)
+	}
+	return c.cache.Get(id) != nil
+}
+
+// maybeRefresh starts a background load of id if its value is due for a
+// refresh and no load for it is already running. The refresh registers in
+// inflight, so misses that race with it wait for its result.
+func (c *LoadingCache) maybeRefresh(id string) {
+	c.mu.Lock()
+	loaded, ok := c.loadedAt[id]
+	if !ok || c.now().Sub(loaded) < c.refreshAfter {
+		c.mu.Unlock()
+		return
+	}
+	if _, busy := c.inflight[id]; busy || c.closed {
+		c.mu.Unlock()
+		return
+	}
+	call := &loadCall{done: make(chan struct{})}
+	c.inflight[id] = call
+	c.background.Add(1)
+	c.mu.Unlock()
+
+	go func() {
+		defer c.background.Done()
+		c.run(id, call)
+	}()
+}
+
+// detach runs call in the background, counted in background so Close waits
+// for it. Once closed it starts nothing and fails call with ErrClosed instead,
+// releasing anyone who joined it.
+func (c *LoadingCache) detach(id string, call *loadCall) {
+	c.mu.Lock()
+	if c.closed {
+		call.err = ErrClosed
+		delete(c.inflight, id)
+		c.mu.Unlock()
+		close(call.done)
+		return
+	}
+	c.background.Add(1)
+	c.mu.Unlock()
+
+	go func() {
+		defer c.background.Done()
+		c.run(id, call)
+	}()
+}
+
+// Close stops starting background loads, both refreshes and the loads behind
+// GetWithLoaderTimeout, and waits for running ones to finish. Get and Update
+// keep working, Get loading synchronously on a miss. It is safe to call more
+// than once.
+func (c *LoadingCache) Close() error {
+	c.mu.Lock()
+	c.closed = true
+	c.mu.Unlock()
+	c.background.Wait()
+	return nil
+}
+
+func (c *LoadingCache) load(id string) (*DiskStatus, error) {
+	call, owner, status := c.join(id)
+	if call == nil {
+		return status, nil
+	}
+	if owner {
+		c.run(id, call)
+	} else {
+		<-call.done
+	}
+	return call.status, call.err
+}
+
+// join returns the running load of id after a miss, registering a new one if
+// there is none; owner reports that the caller must run it. A nil call means
+// the miss was answered without loading, by status.
+func (c *LoadingCache) join(id string) (call *loadCall, owner bool, status *DiskStatus) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if call, ok := c.inflight[id]; ok {
+		return call, false, nil
+	}
+	// A load that finished after our miss has already stored its result, since
+	// results are stored before the call leaves inflight
+	if status := c.cache.Get(id); status != nil {
+		return nil, false, status
+	}
+	if expires, ok := c.negative[id]; ok {
+		if c.now().Before(expires) {
+			return nil, false, nil
+		}
+		delete(c.negative, id)
+	}
+	call = &loadCall{done: make(chan struct{})}
+	c.inflight[id] = call
+	return call, true, nil
+}
+
+// run calls the loader for a call registered in inflight, stores its result
+// unless Update wrote id meanwhile, and releases any waiters. The store is
+// made under c.mu, so it can't land between an Update and its marking call
+// stale.
+func (c *LoadingCache) run(id string, call *loadCall) {
+	c.mu.Lock()
+	call.err = c.allow()
+	c.mu.Unlock()
+	if call.err == nil {
+		call.status, call.err = c.loader(id)
+		c.mu.Lock()
+		c.recordLoad(call.err)
+		c.mu.Unlock()
+	}
+
+	c.mu.Lock()
+	if !call.stale {
+		if call.err == nil {
+			// A nil result deletes, so a refresh that found nothing doesn't
+			// leave the old value behind
+			c.cache.Update(id, call.status)
+		}
+		if call.err == nil && call.status != nil && c.refreshAfter > 0 {
+			c.loadedAt[id] = c.now()
+		}
+		if call.err == nil && call.status == nil {
+			delete(c.loadedAt, id)
+			if c.negativeTTL > 0 {
+				c.negative[id] = c.now().Add(c.negativeTTL)
+			}
+		}
+		delete(c.inflight, id)
+		c.prune()
+	}
+	c.mu.Unlock()
+	close(call.done)
+}
@@ -0,0 +1,377 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingLoader records how many times each id was loaded
+type countingLoader struct {
+	mu    sync.Mutex
+	calls map[string]int
+	delay time.Duration
+}
+
+func newCountingLoader(delay time.Duration) *countingLoader {
+	return &countingLoader{calls: make(map[string]int), delay: delay}
+}
+
+func (l *countingLoader) load(id string) (*DiskStatus, error) {
+	l.mu.Lock()
+	l.calls[id]++
+	l.mu.Unlock()
+	time.Sleep(l.delay)
+	return &DiskStatus{ID: id, Health: 100}, nil
+}
+
+func (l *countingLoader) count(id string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.calls[id]
+}
+
+func TestLoadingCacheCoalescesMisses(t *testing.T) {
+	const goroutines = 50
+	keys := []string{"disk-1", "disk-2", "disk-3"}
+
+	loader := newCountingLoader(20 * time.Millisecond)
+	c := NewLoadingCache(NewShardedCache(), loader.load)
+
+	var wg sync.WaitGroup
+	for _, id := range keys {
+		for i := 0; i < goroutines; i++ {
+			wg.Add(1)
+			go func(id string) {
+				defer wg.Done()
+				got, err := c.Get(id)
+				if err != nil || got == nil || got.ID != id {
+					t.Errorf("expected %s, got (%v, %v)", id, got, err)
+				}
+			}(id)
+		}
+	}
+	wg.Wait()
+
+	for _, id := range keys {
+		if n := loader.count(id); n != 1 {
+			t.Errorf("expected loader to run once for %s, ran %d times", id, n)
+		}
+	}
+
+	// Later reads are served from the cache
+	c.Get("disk-1")
+	if n := loader.count("disk-1"); n != 1 {
+		t.Errorf("expected cached read, loader ran %d times", n)
+	}
+}
+
+func TestLoadingCacheErrors(t *testing.T) {
+	errBackend := errors.New("backend down")
+	calls := 0
+	c := NewLoadingCache(NewMutexCache(), func(id string) (*DiskStatus, error) {
+		calls++
+		if calls == 1 {
+			return nil, errBackend
+		}
+		return &DiskStatus{ID: id}, nil
+	})
+
+	if _, err := c.Get("disk-1"); !errors.Is(err, errBackend) {
+		t.Fatalf("expected backend error, got %v", err)
+	}
+	// Errors aren't cached, so the next Get retries
+	if got, err := c.Get("disk-1"); err != nil || got == nil {
+		t.Errorf("expected retry to load disk-1, got (%v, %v)", got, err)
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 loader calls, got %d", calls)
+	}
+}
+
+func TestLoadingCacheNegativeTTL(t *testing.T) {
+	clock := newFakeClock()
+	calls := 0
+	c := NewLoadingCache(NewMutexCache(), func(id string) (*DiskStatus, error) {
+		calls++
+		return nil, nil // not found
+	}, WithNegativeTTL(time.Minute))
+	c.now = clock.Now
+
+	for i := 0; i < 2; i++ {
+		if got, err := c.Get("disk-1"); got != nil || err != nil {
+			t.Fatalf("expected (nil, nil), got (%v, %v)", got, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 loader call within the negative TTL, got %d", calls)
+	}
+
+	clock.Advance(time.Minute)
+	c.Get("disk-1")
+	if calls != 2 {
+		t.Errorf("expected loader retry after the negative TTL, got %d calls", calls)
+	}
+
+	// An explicit Update replaces the negative entry
+	c.Update("disk-1", &DiskStatus{ID: "disk-1"})
+	if got, _ := c.Get("disk-1"); got == nil {
+		t.Errorf("expected updated disk-1, got nil")
+	}
+}
+
+func TestLoadingCacheRefreshAhead(t *testing.T) {
+	clock := newFakeClock()
+	var calls atomic.Int32
+	release := make(chan struct{})
+	loader := func(id string) (*DiskStatus, error) {
+		n := calls.Add(1)
+		if n > 1 {
+			<-release
+		}
+		return &DiskStatus{ID: id, Temp: int(n)}, nil
+	}
+	inner := NewShardedCache()
+	c := NewLoadingCache(inner, loader, WithRefreshAfter(time.Minute))
+	c.now = clock.Now
+
+	if got, err := c.Get("disk-1"); err != nil || got.Temp != 1 {
+		t.Fatalf("expected first load, got (%v, %v)", got, err)
+	}
+	clock.Advance(30 * time.Second)
+	c.Get("disk-1")
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("expected no refresh before refreshAfter, got %d loads", n)
+	}
+
+	// The refresh loader blocks, so these Gets only return if they serve the
+	// stale value without waiting for it
+	clock.Advance(time.Minute)
+	for i := 0; i < 5; i++ {
+		if got, err := c.Get("disk-1"); err != nil || got.Temp != 1 {
+			t.Fatalf("expected stale value while refreshing, got (%v, %v)", got, err)
+		}
+	}
+	close(release)
+
+	deadline := time.Now().Add(time.Second)
+	for inner.Get("disk-1").Temp != 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected refreshed value, got %v", inner.Get("disk-1"))
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("expected a single background refresh, got %d loads", n)
+	}
+}
+
+func TestLoadingCacheRefreshNotFound(t *testing.T) {
+	clock := newFakeClock()
+	var found atomic.Bool
+	found.Store(true)
+	inner := NewMutexCache()
+	c := NewLoadingCache(inner, func(id string) (*DiskStatus, error) {
+		if !found.Load() {
+			return nil, nil
+		}
+		return &DiskStatus{ID: id}, nil
+	}, WithRefreshAfter(time.Minute))
+	c.now = clock.Now
+	c.Get("disk-1")
+
+	// The id vanished from the store; the refresh must drop the stale copy
+	found.Store(false)
+	clock.Advance(time.Minute)
+	c.Get("disk-1")
+	c.Close() // waits for the refresh
+	if inner.Contains("disk-1") {
+		t.Errorf("expected a not-found refresh to delete disk-1")
+	}
+	c.mu.Lock()
+	_, tracked := c.loadedAt["disk-1"]
+	c.mu.Unlock()
+	if tracked {
+		t.Errorf("expected disk-1's load time dropped")
+	}
+}
+
+func TestLoadingCachePrunes(t *testing.T) {
+	clock := newFakeClock()
+	c := NewLoadingCache(NewLRUCache(10), func(id string) (*DiskStatus, error) {
+		if id[0] == 'm' {
+			return nil, nil // missing-N
+		}
+		return &DiskStatus{ID: id}, nil
+	}, WithNegativeTTL(time.Minute), WithRefreshAfter(time.Hour))
+	c.now = clock.Now
+
+	for i := 0; i < 1000; i++ {
+		c.Get(fmt.Sprintf("missing-%d", i))
+		c.Get(fmt.Sprintf("disk-%d", i)) // evicts from the LRU past 10
+		if i%100 == 99 {
+			clock.Advance(time.Minute)
+		}
+	}
+	// At most 100 negative results are live and 10 ids cached, so with the
+	// doubling both maps stay far below the 2000 ids looked up
+	c.mu.Lock()
+	n := len(c.negative) + len(c.loadedAt)
+	c.mu.Unlock()
+	if n > 4*(100+10)+minPrune {
+		t.Errorf("expected pruned maps, got %d entries", n)
+	}
+}
+
+func TestLoadingCacheCircuitBreaker(t *testing.T) {
+	errBackend := errors.New("backend down")
+	clock := newFakeClock()
+	failing := true
+	calls := 0
+	c := NewLoadingCache(NewMutexCache(), func(id string) (*DiskStatus, error) {
+		calls++
+		if failing {
+			return nil, errBackend
+		}
+		return &DiskStatus{ID: id}, nil
+	}, WithCircuitBreaker(3, time.Minute, 10*time.Second))
+	c.now = clock.Now
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.Get("disk-1"); !errors.Is(err, errBackend) {
+			t.Fatalf("expected backend error, got %v", err)
+		}
+	}
+	if s := c.LoaderStats(); s.State != BreakerOpen || s.ConsecutiveErrors != 3 {
+		t.Fatalf("expected the breaker open after 3 errors, got %+v", s)
+	}
+
+	// Open: fail fast without calling the loader
+	_, err := c.Get("disk-2")
+	if !errors.Is(err, ErrCircuitOpen) || !errors.Is(err, errBackend) {
+		t.Errorf("expected ErrCircuitOpen wrapping the last error, got %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("expected no loader call while open, got %d calls", calls)
+	}
+
+	// A failed half-open trial reopens the circuit
+	clock.Advance(10 * time.Second)
+	if s := c.LoaderStats(); s.State != BreakerHalfOpen {
+		t.Errorf("expected half-open after the cooldown, got %v", s.State)
+	}
+	c.Get("disk-1")
+	if s := c.LoaderStats(); s.State != BreakerOpen || calls != 4 {
+		t.Errorf("expected one trial call reopening the breaker, got %v after %d calls", s.State, calls)
+	}
+
+	// A successful trial closes it
+	clock.Advance(10 * time.Second)
+	failing = false
+	if got, err := c.Get("disk-1"); err != nil || got == nil {
+		t.Fatalf("expected the trial load to succeed, got (%v, %v)", got, err)
+	}
+	s := c.LoaderStats()
+	if s.State != BreakerClosed || s.ConsecutiveErrors != 0 {
+		t.Errorf("expected the breaker closed, got %+v", s)
+	}
+	if s.Loads != 5 || s.Errors != 4 || s.Rejected != 1 {
+		t.Errorf("expected 5 loads, 4 errors and 1 rejection, got %+v", s)
+	}
+}
+
+func TestLoadingCacheCircuitBreakerWindow(t *testing.T) {
+	clock := newFakeClock()
+	c := NewLoadingCache(NewMutexCache(), func(id string) (*DiskStatus, error) {
+		return nil, errors.New("flaky")
+	}, WithCircuitBreaker(2, time.Minute, time.Minute))
+	c.now = clock.Now
+
+	// Errors further apart than the window don't add up
+	for i := 0; i < 3; i++ {
+		c.Get("disk-1")
+		clock.Advance(2 * time.Minute)
+	}
+	if s := c.LoaderStats(); s.State != BreakerClosed || s.ConsecutiveErrors != 1 {
+		t.Errorf("expected the breaker to stay closed, got %+v", s)
+	}
+}
+
+func TestLoadingCacheGetWithLoaderTimeout(t *testing.T) {
+	release := make(chan struct{})
+	var calls atomic.Int32
+	c := NewLoadingCache(NewShardedCache(), func(id string) (*DiskStatus, error) {
+		calls.Add(1)
+		<-release
+		return &DiskStatus{ID: id, Health: 100}, nil
+	})
+
+	// Concurrent callers share the one hanging load and all time out
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got, err := c.GetWithLoaderTimeout("disk-1", 20*time.Millisecond); !errors.Is(err, ErrLoadTimeout) {
+				t.Errorf("expected ErrLoadTimeout, got (%v, %v)", got, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := calls.Load(); n != 1 {
+		t.Errorf("expected one loader call, got %d", n)
+	}
+
+	// The load finishes in the background and later callers find its result
+	close(release)
+	deadline := time.Now().Add(time.Second)
+	for {
+		got, err := c.GetWithLoaderTimeout("disk-1", 20*time.Millisecond)
+		if err == nil && got != nil && got.ID == "disk-1" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the background load to be cached, got (%v, %v)", got, err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if got, err := c.Get("disk-1"); err != nil || got == nil {
+		t.Errorf("expected a cached hit, got (%v, %v)", got, err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("expected no further loader calls, got %d", n)
+	}
+}
+
+// A load that was running when Update wrote the id must not overwrite it
+func TestLoadingCacheUpdateDuringLoad(t *testing.T) {
+	for _, newer := range []*DiskStatus{{ID: "disk-1", Temp: 50}, nil} {
+		entered, release := make(chan struct{}), make(chan struct{})
+		m := NewMutexCache()
+		c := NewLoadingCache(m, func(id string) (*DiskStatus, error) {
+			close(entered)
+			<-release
+			return &DiskStatus{ID: id, Temp: 40}, nil
+		})
+
+		loaded := make(chan *DiskStatus)
+		go func() {
+			status, _ := c.Get("disk-1")
+			loaded <- status
+		}()
+		<-entered
+		c.Update("disk-1", newer)
+		close(release)
+
+		// The waiting Get still gets its load's result
+		if got := <-loaded; got == nil || got.Temp != 40 {
+			t.Errorf("expected the loaded value for the waiting Get, got %v", got)
+		}
+		if got := m.Get("disk-1"); got != newer {
+			t.Errorf("expected %v to survive the load, got %v", newer, got)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package cache
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// lockWaitBounds are the upper bounds of the LockWaitStats buckets.
+var lockWaitBounds = [...]time.Duration{
+	time.Microsecond,
+	10 * time.Microsecond,
+	100 * time.Microsecond,
+	time.Millisecond,
+	10 * time.Millisecond,
+}
+
+// LockWaitStats is a histogram of how long operations waited to acquire a
+// cache's lock. Buckets[i] counts waits up to lockWaitBounds[i] (1µs, 10µs,
+// 100µs, 1ms, 10ms); the last bucket counts everything longer.
+type LockWaitStats struct {
+	Count   uint64
+	Total   time.Duration
+	Max     time.Duration
+	Buckets [len(lockWaitBounds) + 1]uint64
+}
+
+// lockWaits records lock waits with atomics, so recording never needs the
+// lock being measured.
+type lockWaits struct {
+	count   atomic.Uint64
+	total   atomic.Int64
+	max     atomic.Int64
+	buckets [len(lockWaitBounds) + 1]atomic.Uint64
+}
+
+func (w *lockWaits) record(d time.Duration) {
+	w.count.Add(1)
+	w.total.Add(int64(d))
+	for {
+		old := w.max.Load()
+		if int64(d) <= old || w.max.CompareAndSwap(old, int64(d)) {
+			break
+		}
+	}
+	i := 0
+	for i < len(lockWaitBounds) && d > lockWaitBounds[i] {
+		i++
+	}
+	w.buckets[i].Add(1)
+}
+
+func (w *lockWaits) stats() LockWaitStats {
+	s := LockWaitStats{
+		Count: w.count.Load(),
+		Total: time.Duration(w.total.Load()),
+		Max:   time.Duration(w.max.Load()),
+	}
+	for i := range w.buckets {
+		s.Buckets[i] = w.buckets[i].Load()
+	}
+	return s
+}
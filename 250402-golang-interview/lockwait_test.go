@@ -0,0 +1,44 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMutexCacheLockWaitStats(t *testing.T) {
+	t.Run("Contended", func(t *testing.T) {
+		const hold = 20 * time.Millisecond
+		c := NewInstrumentedMutexCache()
+		c.Update("disk-1", &DiskStatus{ID: "disk-1"})
+
+		c.base.mu.Lock()
+		done := make(chan struct{})
+		go func() {
+			c.Get("disk-1")
+			close(done)
+		}()
+		time.Sleep(hold)
+		c.base.mu.Unlock()
+		<-done
+
+		s := c.LockWaitStats()
+		if s.Count != 2 {
+			t.Errorf("expected 2 recorded waits, got %d", s.Count)
+		}
+		if s.Max < hold/2 || s.Total < s.Max {
+			t.Errorf("expected a wait near %v, got max %v total %v", hold, s.Max, s.Total)
+		}
+		// The uncontended Update lands in the first bucket, the Get in the last
+		if s.Buckets[0] != 1 || s.Buckets[len(s.Buckets)-1] != 1 {
+			t.Errorf("expected one fast and one >10ms wait, got %v", s.Buckets)
+		}
+	})
+
+	t.Run("Uninstrumented", func(t *testing.T) {
+		c := NewMutexCache()
+		c.Update("disk-1", &DiskStatus{ID: "disk-1"})
+		if s := c.LockWaitStats(); s != (LockWaitStats{}) {
+			t.Errorf("expected zero stats, got %+v", s)
+		}
+	})
+}
@@ -0,0 +1,135 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+)
+
+// 8. LRU Cache (bounded)
+//
+// A map gives O(1) lookup and a doubly linked list keeps entries in recency
+// order, most recently used at the front. Every Get moves an element, so even
+// reads need the exclusive lock.
+type LRUCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	ll         *list.List
+	items      map[string]*list.Element
+	onEvict    evictions
+}
+
+type lruEntry struct {
+	id     string
+	status *DiskStatus
+}
+
+var _ Cache = (*LRUCache)(nil)
+
+// NewLRUCache returns a cache holding at most maxEntries entries. maxEntries
+// <= 0 means no limit.
+func NewLRUCache(maxEntries int) *LRUCache {
+	return &LRUCache{
+		maxEntries: maxEntries,
+		ll:         list.New(),
+		items:      make(map[string]*list.Element),
+	}
+}
+
+func (c *LRUCache) Get(id string) *DiskStatus {
+	c.mu.Lock()
+	defer c.unlock()
+	el, ok := c.items[id]
+	if !ok {
+		return nil
+	}
+	c.ll.MoveToFront(el)
+	return el.Value.(*lruEntry).status
+}
+
+// Update stores status as the most recently used entry, evicting the least
+// recently used one if the cache is full.
+func (c *LRUCache) Update(id string, status *DiskStatus) {
+	if status == nil {
+		c.Delete(id)
+		return
+	}
+	c.mu.Lock()
+	defer c.unlock()
+	if el, ok := c.items[id]; ok {
+		el.Value.(*lruEntry).status = status
+		c.ll.MoveToFront(el)
+		return
+	}
+	c.items[id] = c.ll.PushFront(&lruEntry{id: id, status: status})
+	if c.maxEntries > 0 && c.ll.Len() > c.maxEntries {
+		c.removeElement(c.ll.Back())
+	}
+}
+
+// OnEvict registers fn to be called after an entry leaves the cache through
+// eviction or Delete. fn runs outside the lock, so it may call back into the
+// cache.
+func (c *LRUCache) OnEvict(fn func(id string, status *DiskStatus)) {
+	c.mu.Lock()
+	defer c.unlock()
+	c.onEvict.fn = fn
+}
+
+// unlock releases c.mu, then reports entries removed while it was held.
+func (c *LRUCache) unlock() {
+	fn, removed := c.onEvict.take()
+	c.mu.Unlock()
+	notify(fn, removed)
+}
+
+// Peek is Get without counting as an access, so monitoring can read entries
+// without changing which one is evicted next.
+func (c *LRUCache) Peek(id string) *DiskStatus {
+	c.mu.Lock()
+	defer c.unlock()
+	el, ok := c.items[id]
+	if !ok {
+		return nil
+	}
+	return el.Value.(*lruEntry).status
+}
+
+// Contains reports presence without counting as an access.
+func (c *LRUCache) Contains(id string) bool {
+	c.mu.Lock()
+	defer c.unlock()
+	_, ok := c.items[id]
+	return ok
+}
+
+// Snapshot copies all entries without counting as accesses.
+func (c *LRUCache) Snapshot() map[string]*DiskStatus {
+	c.mu.Lock()
+	defer c.unlock()
+	snap := make(map[string]*DiskStatus, len(c.items))
+	for id, el := range c.items {
+		snap[id] = cloneStatus(el.Value.(*lruEntry).status)
+	}
+	return snap
+}
+
+func (c *LRUCache) Delete(id string) {
+	c.mu.Lock()
+	defer c.unlock()
+	if el, ok := c.items[id]; ok {
+		c.removeElement(el)
+	}
+}
+
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.unlock()
+	return c.ll.Len()
+}
+
+func (c *LRUCache) removeElement(el *list.Element) {
+	e := el.Value.(*lruEntry)
+	c.ll.Remove(el)
+	delete(c.items, e.id)
+	c.onEvict.add(e.id, e.status)
+}
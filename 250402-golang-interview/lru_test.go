@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestLRUCacheEviction(t *testing.T) {
+	const maxEntries = 3
+
+	t.Run("OldestEvicted", func(t *testing.T) {
+		c := NewLRUCache(maxEntries)
+		for i := 0; i <= maxEntries; i++ {
+			id := fmt.Sprintf("disk-%d", i)
+			c.Update(id, &DiskStatus{ID: id})
+		}
+		if got := c.Get("disk-0"); got != nil {
+			t.Errorf("expected disk-0 to be evicted, got %v", got)
+		}
+		for i := 1; i <= maxEntries; i++ {
+			id := fmt.Sprintf("disk-%d", i)
+			if got := c.Get(id); got == nil {
+				t.Errorf("expected %s to survive", id)
+			}
+		}
+		if got := c.Len(); got != maxEntries {
+			t.Errorf("expected Len %d, got %d", maxEntries, got)
+		}
+	})
+
+	t.Run("GetRefreshesRecency", func(t *testing.T) {
+		c := NewLRUCache(maxEntries)
+		for i := 0; i < maxEntries; i++ {
+			id := fmt.Sprintf("disk-%d", i)
+			c.Update(id, &DiskStatus{ID: id})
+		}
+		// Touch the oldest so disk-1 becomes the least recently used
+		c.Get("disk-0")
+		c.Update("disk-3", &DiskStatus{ID: "disk-3"})
+
+		if got := c.Get("disk-1"); got != nil {
+			t.Errorf("expected disk-1 to be evicted, got %v", got)
+		}
+		if got := c.Get("disk-0"); got == nil {
+			t.Errorf("expected recently read disk-0 to survive")
+		}
+	})
+
+	t.Run("PeekKeepsOrder", func(t *testing.T) {
+		c := NewLRUCache(maxEntries)
+		for i := 0; i < maxEntries; i++ {
+			id := fmt.Sprintf("disk-%d", i)
+			c.Update(id, &DiskStatus{ID: id})
+		}
+		for i := 0; i < 5; i++ {
+			if got := c.Peek("disk-0"); got == nil || got.ID != "disk-0" {
+				t.Fatalf("expected to peek disk-0, got %v", got)
+			}
+		}
+		c.Update("disk-3", &DiskStatus{ID: "disk-3"})
+		if c.Contains("disk-0") {
+			t.Errorf("expected peeked disk-0 to still be evicted first")
+		}
+		if got := c.Peek("disk-0"); got != nil {
+			t.Errorf("expected nil peeking a missing key, got %v", got)
+		}
+	})
+
+	t.Run("OverwriteDoesNotEvict", func(t *testing.T) {
+		c := NewLRUCache(maxEntries)
+		for i := 0; i < maxEntries; i++ {
+			id := fmt.Sprintf("disk-%d", i)
+			c.Update(id, &DiskStatus{ID: id})
+		}
+		c.Update("disk-0", &DiskStatus{ID: "disk-0", Temp: 50})
+		if got := c.Len(); got != maxEntries {
+			t.Errorf("expected Len %d, got %d", maxEntries, got)
+		}
+		if got := c.Get("disk-0"); got == nil || got.Temp != 50 {
+			t.Errorf("expected updated disk-0, got %v", got)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		c := NewLRUCache(maxEntries)
+		c.Update("disk-0", &DiskStatus{ID: "disk-0"})
+		c.Delete("disk-0")
+		if got := c.Get("disk-0"); got != nil || c.Len() != 0 {
+			t.Errorf("expected empty cache after delete, got %v (Len %d)", got, c.Len())
+		}
+	})
+}
@@ -0,0 +1,28 @@
+package cache
+
+import "sync/atomic"
+
+// keyNormalizer is embedded by caches that support SetKeyNormalizer.
+type keyNormalizer struct {
+	fn atomic.Pointer[func(string) string]
+}
+
+// SetKeyNormalizer makes every method that takes an id pass it through fn
+// first, so ids that differ only in spelling (e.g. case or surrounding
+// whitespace) share one entry. Set it before the cache fills up: existing
+// entries keep the ids they were stored under. A nil fn turns normalization
+// off.
+func (k *keyNormalizer) SetKeyNormalizer(fn func(string) string) {
+	if fn == nil {
+		k.fn.Store(nil)
+		return
+	}
+	k.fn.Store(&fn)
+}
+
+func (k *keyNormalizer) normalize(id string) string {
+	if fn := k.fn.Load(); fn != nil {
+		return (*fn)(id)
+	}
+	return id
+}
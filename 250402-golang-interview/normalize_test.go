@@ -0,0 +1,148 @@
+package cache
+
+import (
+	"maps"
+	"strings"
+	"testing"
+	"time"
+)
+
+func normalizeID(id string) string { return strings.ToLower(strings.TrimSpace(id)) }
+
+func TestSetKeyNormalizer(t *testing.T) {
+	caches := []struct {
+		name string
+		c    interface {
+			Cache
+			Contains(id string) bool
+			Delete(id string)
+			Len() int
+			SetKeyNormalizer(fn func(string) string)
+		}
+	}{
+		{"MutexCache", NewMutexCache()},
+		{"ShardedCache", NewShardedCache()},
+	}
+
+	for _, tc := range caches {
+		t.Run(tc.name, func(t *testing.T) {
+			c := tc.c
+			c.SetKeyNormalizer(normalizeID)
+			status := &DiskStatus{ID: "disk-1", Temp: 40}
+			c.Update("Disk-1", status)
+
+			for _, id := range []string{"disk-1", "DISK-1", " Disk-1 "} {
+				if got := c.Get(id); got != status {
+					t.Errorf("expected %q to hit, got %v", id, got)
+				}
+				if !c.Contains(id) {
+					t.Errorf("expected Contains(%q)", id)
+				}
+			}
+			c.Update("disk-1 ", &DiskStatus{ID: "disk-1", Temp: 41})
+			if got := c.Len(); got != 1 {
+				t.Errorf("expected one entry, got %d", got)
+			}
+			c.Delete("DISK-1")
+			if c.Contains("disk-1") {
+				t.Errorf("expected Delete to remove the normalized entry")
+			}
+
+			c.SetKeyNormalizer(nil)
+			c.Update("Disk-2", status)
+			if c.Contains("disk-2") {
+				t.Errorf("expected exact ids once normalization is off")
+			}
+		})
+	}
+}
+
+func TestSetKeyNormalizerEveryMethod(t *testing.T) {
+	next := &DiskStatus{ID: "disk-1", Temp: 50}
+	type normWrite struct {
+		name  string
+		write func(c writeCache)
+		want  map[string]int // id to Temp afterwards
+	}
+	common := []normWrite{
+		{"CompareAndUpdate", func(c writeCache) { c.CompareAndUpdate(" DISK-1 ", c.Get("disk-1"), next) }, map[string]int{"disk-1": 50}},
+		{"UpdateIfAbsent", func(c writeCache) { c.UpdateIfAbsent("DISK-1", next) }, map[string]int{"disk-1": 30}},
+		{"Swap", func(c writeCache) { c.Swap("DISK-1", next) }, map[string]int{"disk-1": 50}},
+		{"UpdateFunc", func(c writeCache) {
+			c.UpdateFunc("DISK-1", func(*DiskStatus) *DiskStatus { return next })
+		}, map[string]int{"disk-1": 50}},
+		{"IncrementTemp", func(c writeCache) { c.IncrementTemp("DISK-1", 5) }, map[string]int{"disk-1": 35}},
+		{"UpdateBatch", func(c writeCache) { c.UpdateBatch(map[string]*DiskStatus{"DISK-1": next}) }, map[string]int{"disk-1": 50}},
+		{"GetAndDelete", func(c writeCache) { c.GetAndDelete("DISK-1") }, map[string]int{}},
+		{"GetOrCompute", func(c writeCache) {
+			c.GetOrCompute("DISK-1", func() *DiskStatus { return next })
+		}, map[string]int{"disk-1": 30}},
+	}
+	caches := []struct {
+		name  string
+		new   func() writeCache
+		extra []normWrite
+	}{
+		{"MutexCache", func() writeCache { return NewMutexCache() }, []normWrite{
+			{"UpdateWithTTL", func(c writeCache) {
+				c.(*MutexCache).UpdateWithTTL("DISK-1", next, time.Minute)
+			}, map[string]int{"disk-1": 50}},
+			{"ReplaceAll", func(c writeCache) {
+				c.(*MutexCache).ReplaceAll(map[string]*DiskStatus{"DISK-1": next})
+			}, map[string]int{"disk-1": 50}},
+		}},
+		{"ShardedCache", func() writeCache { return NewShardedCache() }, []normWrite{
+			{"Rename", func(c writeCache) { c.(*ShardedCache).Rename("DISK-1", " Disk-2") }, map[string]int{"disk-2": 30}},
+			{"WithShard", func(c writeCache) {
+				c.(*ShardedCache).WithShard("DISK-1", func(m map[string]*DiskStatus) { delete(m, "disk-1") })
+			}, map[string]int{}},
+		}},
+	}
+	for _, impl := range caches {
+		for _, w := range append(common, impl.extra...) {
+			t.Run(impl.name+"/"+w.name, func(t *testing.T) {
+				c := impl.new()
+				c.SetKeyNormalizer(normalizeID)
+				c.Update("disk-1", &DiskStatus{ID: "disk-1", Temp: 30})
+				w.write(c)
+				got := make(map[string]int)
+				for id, status := range c.Snapshot() {
+					got[id] = status.Temp
+				}
+				if !maps.Equal(got, w.want) {
+					t.Errorf("expected %v, got %v", w.want, got)
+				}
+			})
+		}
+	}
+
+	t.Run("Reads", func(t *testing.T) {
+		m, s := NewMutexCache(), NewShardedCache()
+		for _, c := range []writeCache{m, s} {
+			c.SetKeyNormalizer(normalizeID)
+			c.Update("disk-1", next)
+		}
+		if _, _, ok := m.GetWithExpiry("DISK-1"); !ok {
+			t.Errorf("expected GetWithExpiry to normalize")
+		}
+		if _, _, ok := m.GetVersioned("DISK-1"); !ok {
+			t.Errorf("expected GetVersioned to normalize")
+		}
+		if _, _, _, ok := m.GetMeta("DISK-1"); !ok {
+			t.Errorf("expected GetMeta to normalize")
+		}
+		if s.ShardOf("DISK-1") != s.ShardOf("disk-1") {
+			t.Errorf("expected ShardOf to normalize")
+		}
+		batches := map[string]func([]string) map[string]*DiskStatus{
+			"Mutex.GetBatch":   m.GetBatch,
+			"Sharded.GetBatch": s.GetBatch,
+			"GetMultiParallel": s.GetMultiParallel,
+		}
+		for name, get := range batches {
+			if got := get([]string{"DISK-1"}); got["DISK-1"] != next {
+				t.Errorf("%s: expected a hit keyed by the given id, got %v", name, got)
+			}
+		}
+	})
+}
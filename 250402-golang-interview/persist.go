@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// MarshalJSON encodes the cache as an object mapping id to DiskStatus. TTLs
+// are not persisted.
+func (c *MutexCache) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.Snapshot())
+}
+
+// UnmarshalJSON merges the encoded entries into the cache under its lock; a
+// null value deletes its id. The cache must have been created with
+// NewMutexCache.
+func (c *MutexCache) UnmarshalJSON(data []byte) error {
+	var m map[string]*DiskStatus
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	c.UpdateBatch(m)
+	return nil
+}
+
+// MarshalJSON encodes the cache as an object mapping id to DiskStatus.
+func (c *ShardedCache) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.Snapshot())
+}
+
+// UnmarshalJSON merges the encoded entries into the cache, taking each shard
+// lock once; a null value deletes its id. The cache must have been created
+// with a ShardedCache constructor.
+func (c *ShardedCache) UnmarshalJSON(data []byte) error {
+	var m map[string]*DiskStatus
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	c.UpdateBatch(m)
+	return nil
+}
+
+// SaveToFile writes the cache to path with encoding/gob. It writes a temp file
+// in the same directory and renames it into place, so a crash mid-write never
+// leaves a truncated file at path.
+func (c *ShardedCache) SaveToFile(path string) (err error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("cache: save %s: %w", path, err)
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if err := gob.NewEncoder(tmp).Encode(c.Snapshot()); err != nil {
+		return fmt.Errorf("cache: save %s: %w", path, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return fmt.Errorf("cache: save %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("cache: save %s: %w", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("cache: save %s: %w", path, err)
+	}
+	return nil
+}
+
+// LoadFromFile merges entries saved by SaveToFile into the cache, through
+// UpdateBatch, so a nil value deletes its id. A missing file yields an error
+// matching fs.ErrNotExist.
+func (c *ShardedCache) LoadFromFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("cache: load: %w", err)
+	}
+	defer f.Close()
+
+	var m map[string]*DiskStatus
+	if err := gob.NewDecoder(f).Decode(&m); err != nil {
+		return fmt.Errorf("cache: load %s: %w", path, err)
+	}
+	c.UpdateBatch(m)
+	return nil
+}
@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	type snapshotter interface {
+		Cache
+		Snapshot() map[string]*DiskStatus
+	}
+
+	caches := []struct {
+		name string
+		new  func() snapshotter
+	}{
+		{"MutexCache", func() snapshotter { return NewMutexCache() }},
+		{"ShardedCache", func() snapshotter { return NewShardedCache() }},
+	}
+
+	for _, tc := range caches {
+		t.Run(tc.name, func(t *testing.T) {
+			src := tc.new()
+			initCache(src)
+			src.Update("disk-1", &DiskStatus{ID: "disk-1", Health: 42, Temp: 61})
+
+			data, err := json.Marshal(src)
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+
+			dst := tc.new()
+			if err := json.Unmarshal(data, dst); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			if want, got := src.Snapshot(), dst.Snapshot(); !reflect.DeepEqual(want, got) {
+				t.Errorf("round trip mismatch: %d entries before, %d after", len(want), len(got))
+			}
+		})
+	}
+
+	t.Run("InvalidJSON", func(t *testing.T) {
+		if err := json.Unmarshal([]byte(`{"disk-1": 5}`), NewShardedCache()); err == nil {
+			t.Error("expected an error for malformed entries")
+		}
+	})
+}
+
+func TestGobFilePersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "disks.gob")
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		src := NewShardedCache()
+		initCache(src)
+		if err := src.SaveToFile(path); err != nil {
+			t.Fatalf("save: %v", err)
+		}
+
+		dst := NewShardedCache()
+		if err := dst.LoadFromFile(path); err != nil {
+			t.Fatalf("load: %v", err)
+		}
+		if want, got := src.Snapshot(), dst.Snapshot(); !reflect.DeepEqual(want, got) {
+			t.Errorf("round trip mismatch: %d entries before, %d after", len(want), len(got))
+		}
+
+		// No temp files are left behind
+		entries, err := os.ReadDir(filepath.Dir(path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 1 {
+			t.Errorf("expected only %s in the directory, found %d entries", path, len(entries))
+		}
+	})
+
+	t.Run("MissingFile", func(t *testing.T) {
+		err := NewShardedCache().LoadFromFile(filepath.Join(t.TempDir(), "missing.gob"))
+		if !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("expected fs.ErrNotExist, got %v", err)
+		}
+	})
+
+	t.Run("CorruptFile", func(t *testing.T) {
+		bad := filepath.Join(t.TempDir(), "bad.gob")
+		if err := os.WriteFile(bad, []byte("not gob"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := NewShardedCache().LoadFromFile(bad); err == nil {
+			t.Error("expected an error for a corrupt file")
+		}
+	})
+}
@@ -0,0 +1,226 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+)
+
+// EvictionPolicy decides which entry a PolicyCache drops when it is full.
+// PolicyCache calls it under its own lock, so implementations need no locking
+// of their own.
+type EvictionPolicy interface {
+	// RecordAccess notes a Get hit or an overwrite of an existing id.
+	RecordAccess(id string)
+	// RecordAdd notes that id was inserted.
+	RecordAdd(id string)
+	// Evict picks a victim and forgets it. ok is false if nothing is tracked.
+	Evict() (id string, ok bool)
+	// Remove forgets id, which was deleted. Untracked ids are ignored.
+	Remove(id string)
+}
+
+// PolicyCache is a bounded cache whose eviction order comes from an injected
+// EvictionPolicy, so LRU, LFU or a custom strategy share one storage layer.
+type PolicyCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	policy     EvictionPolicy
+	disks      map[string]*DiskStatus
+}
+
+var _ Cache = (*PolicyCache)(nil)
+
+// NewPolicyCache returns a cache holding at most maxEntries entries, evicting
+// whatever policy chooses. maxEntries <= 0 means no limit.
+func NewPolicyCache(maxEntries int, policy EvictionPolicy) *PolicyCache {
+	return &PolicyCache{
+		maxEntries: maxEntries,
+		policy:     policy,
+		disks:      make(map[string]*DiskStatus),
+	}
+}
+
+func (c *PolicyCache) Get(id string) *DiskStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	status, ok := c.disks[id]
+	if ok {
+		c.policy.RecordAccess(id)
+	}
+	return status
+}
+
+func (c *PolicyCache) Update(id string, status *DiskStatus) {
+	if status == nil {
+		c.Delete(id)
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.disks[id]; ok {
+		c.disks[id] = status
+		c.policy.RecordAccess(id)
+		return
+	}
+	if c.maxEntries > 0 && len(c.disks) >= c.maxEntries {
+		if victim, ok := c.policy.Evict(); ok {
+			delete(c.disks, victim)
+		}
+	}
+	c.disks[id] = status
+	c.policy.RecordAdd(id)
+}
+
+// Contains reports presence without counting as an access.
+func (c *PolicyCache) Contains(id string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.disks[id]
+	return ok
+}
+
+func (c *PolicyCache) Delete(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.disks[id]; ok {
+		delete(c.disks, id)
+		c.policy.Remove(id)
+	}
+}
+
+func (c *PolicyCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.disks)
+}
+
+// LRUPolicy evicts the least recently used id.
+type LRUPolicy struct {
+	ll    *list.List // most recently used at the front
+	elems map[string]*list.Element
+}
+
+var _ EvictionPolicy = (*LRUPolicy)(nil)
+
+func NewLRUPolicy() *LRUPolicy {
+	return &LRUPolicy{ll: list.New(), elems: make(map[string]*list.Element)}
+}
+
+func (p *LRUPolicy) RecordAccess(id string) {
+	if el, ok := p.elems[id]; ok {
+		p.ll.MoveToFront(el)
+	}
+}
+
+func (p *LRUPolicy) RecordAdd(id string) {
+	if el, ok := p.elems[id]; ok {
+		p.ll.MoveToFront(el)
+		return
+	}
+	p.elems[id] = p.ll.PushFront(id)
+}
+
+func (p *LRUPolicy) Evict() (string, bool) {
+	el := p.ll.Back()
+	if el == nil {
+		return "", false
+	}
+	id := p.ll.Remove(el).(string)
+	delete(p.elems, id)
+	return id, true
+}
+
+func (p *LRUPolicy) Remove(id string) {
+	if el, ok := p.elems[id]; ok {
+		p.ll.Remove(el)
+		delete(p.elems, id)
+	}
+}
+
+// LFUPolicy evicts the least frequently used id, breaking ties by least
+// recently used. It uses the same frequency buckets as LFUCache.
+type LFUPolicy struct {
+	elems   map[string]*list.Element
+	freqs   map[int]*list.List
+	minFreq int
+}
+
+type lfuPolicyEntry struct {
+	id   string
+	freq int
+}
+
+var _ EvictionPolicy = (*LFUPolicy)(nil)
+
+func NewLFUPolicy() *LFUPolicy {
+	return &LFUPolicy{elems: make(map[string]*list.Element), freqs: make(map[int]*list.List)}
+}
+
+func (p *LFUPolicy) RecordAccess(id string) {
+	el, ok := p.elems[id]
+	if !ok {
+		return
+	}
+	e := el.Value.(*lfuPolicyEntry)
+	p.unlink(el)
+	if e.freq == p.minFreq && p.freqs[e.freq] == nil {
+		p.minFreq++
+	}
+	e.freq++
+	p.elems[id] = p.bucket(e.freq).PushFront(e)
+}
+
+func (p *LFUPolicy) RecordAdd(id string) {
+	if _, ok := p.elems[id]; ok {
+		p.RecordAccess(id)
+		return
+	}
+	p.elems[id] = p.bucket(1).PushFront(&lfuPolicyEntry{id: id, freq: 1})
+	p.minFreq = 1
+}
+
+func (p *LFUPolicy) Evict() (string, bool) {
+	if len(p.elems) == 0 {
+		return "", false
+	}
+	id := p.freqs[p.minFreq].Back().Value.(*lfuPolicyEntry).id
+	p.Remove(id)
+	return id, true
+}
+
+func (p *LFUPolicy) Remove(id string) {
+	el, ok := p.elems[id]
+	if !ok {
+		return
+	}
+	p.unlink(el)
+	delete(p.elems, id)
+	// Unlike LFUCache, no insert is guaranteed to follow, so find the next
+	// lowest bucket if this one emptied
+	if p.freqs[p.minFreq] == nil && len(p.elems) > 0 {
+		p.minFreq = 0
+		for freq := range p.freqs {
+			if p.minFreq == 0 || freq < p.minFreq {
+				p.minFreq = freq
+			}
+		}
+	}
+}
+
+func (p *LFUPolicy) unlink(el *list.Element) {
+	freq := el.Value.(*lfuPolicyEntry).freq
+	l := p.freqs[freq]
+	l.Remove(el)
+	if l.Len() == 0 {
+		delete(p.freqs, freq)
+	}
+}
+
+func (p *LFUPolicy) bucket(freq int) *list.List {
+	l, ok := p.freqs[freq]
+	if !ok {
+		l = list.New()
+		p.freqs[freq] = l
+	}
+	return l
+}
@@ -0,0 +1,138 @@
+package cache
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// recordingPolicy logs every hook call and evicts ids in insertion order
+type recordingPolicy struct {
+	calls []string
+	added []string
+}
+
+func (p *recordingPolicy) RecordAccess(id string) {
+	p.calls = append(p.calls, "access:"+id)
+}
+
+func (p *recordingPolicy) RecordAdd(id string) {
+	p.calls = append(p.calls, "add:"+id)
+	p.added = append(p.added, id)
+}
+
+func (p *recordingPolicy) Evict() (string, bool) {
+	if len(p.added) == 0 {
+		return "", false
+	}
+	id := p.added[0]
+	p.added = p.added[1:]
+	p.calls = append(p.calls, "evict:"+id)
+	return id, true
+}
+
+func (p *recordingPolicy) Remove(id string) {
+	p.calls = append(p.calls, "remove:"+id)
+	p.added = slices.DeleteFunc(p.added, func(a string) bool { return a == id })
+}
+
+func TestPolicyCacheHooks(t *testing.T) {
+	p := &recordingPolicy{}
+	c := NewPolicyCache(2, p)
+
+	c.Update("disk-1", &DiskStatus{ID: "disk-1"})
+	c.Update("disk-2", &DiskStatus{ID: "disk-2"})
+	c.Get("disk-1")
+	c.Get("missing")
+	c.Contains("disk-2")
+	c.Update("disk-2", &DiskStatus{ID: "disk-2", Temp: 50})
+	c.Update("disk-3", &DiskStatus{ID: "disk-3"})
+
+	want := []string{"add:disk-1", "add:disk-2", "access:disk-1", "access:disk-2", "evict:disk-1", "add:disk-3"}
+	if !slices.Equal(p.calls, want) {
+		t.Errorf("expected calls %v, got %v", want, p.calls)
+	}
+	if c.Contains("disk-1") {
+		t.Errorf("expected the policy's victim disk-1 to be evicted")
+	}
+	if got := c.Len(); got != 2 {
+		t.Errorf("expected Len 2, got %d", got)
+	}
+}
+
+func TestPolicyCachePolicies(t *testing.T) {
+	const maxEntries = 3
+	fill := func(c *PolicyCache) {
+		for i := 0; i < maxEntries; i++ {
+			id := fmt.Sprintf("disk-%d", i)
+			c.Update(id, &DiskStatus{ID: id})
+		}
+	}
+
+	t.Run("LRU", func(t *testing.T) {
+		c := NewPolicyCache(maxEntries, NewLRUPolicy())
+		fill(c)
+		// Touch the oldest so disk-1 becomes the least recently used
+		c.Get("disk-0")
+		c.Update("disk-3", &DiskStatus{ID: "disk-3"})
+		if c.Contains("disk-1") || !c.Contains("disk-0") {
+			t.Errorf("expected disk-1 evicted and disk-0 kept")
+		}
+	})
+
+	t.Run("LFU", func(t *testing.T) {
+		c := NewPolicyCache(maxEntries, NewLFUPolicy())
+		fill(c)
+		c.Get("disk-0")
+		c.Get("disk-0")
+		c.Get("disk-1")
+		c.Update("disk-3", &DiskStatus{ID: "disk-3"})
+		if c.Contains("disk-2") || !c.Contains("disk-0") || !c.Contains("disk-1") {
+			t.Errorf("expected only the never-read disk-2 to be evicted")
+		}
+	})
+
+	t.Run("ForgetsDeleted", func(t *testing.T) {
+		c := NewPolicyCache(maxEntries, NewLRUPolicy())
+		fill(c)
+		// Delete removed disk-0 from the policy too, so disk-1 is the oldest
+		c.Delete("disk-0")
+		c.Update("disk-3", &DiskStatus{ID: "disk-3"})
+		c.Update("disk-4", &DiskStatus{ID: "disk-4"})
+		if c.Len() != maxEntries || c.Contains("disk-1") || !c.Contains("disk-2") {
+			t.Errorf("expected disk-1 evicted past the deleted disk-0, Len %d", c.Len())
+		}
+	})
+
+	t.Run("RemoveForgetsID", func(t *testing.T) {
+		for _, p := range []EvictionPolicy{NewLRUPolicy(), NewLFUPolicy()} {
+			p.RecordAdd("a")
+			p.RecordAdd("b")
+			p.RecordAccess("a")
+			p.Remove("b")
+			p.Remove("missing")
+			if got, ok := p.Evict(); !ok || got != "a" {
+				t.Errorf("%T: expected to evict a, got (%s, %v)", p, got, ok)
+			}
+			if got, ok := p.Evict(); ok {
+				t.Errorf("%T: expected nothing left to evict, got %s", p, got)
+			}
+		}
+	})
+
+	t.Run("LFUEvictEmptiesMinBucket", func(t *testing.T) {
+		p := NewLFUPolicy()
+		p.RecordAdd("a")
+		p.RecordAdd("b")
+		p.RecordAccess("b")
+		p.RecordAccess("b")
+		for _, want := range []string{"a", "b"} {
+			if got, ok := p.Evict(); !ok || got != want {
+				t.Errorf("expected to evict %s, got (%s, %v)", want, got, ok)
+			}
+		}
+		if got, ok := p.Evict(); ok {
+			t.Errorf("expected nothing left to evict, got %s", got)
+		}
+	})
+}
@@ -0,0 +1,49 @@
+// Package promcache exports cache metrics to Prometheus. It lives in its own
+// module so the main package doesn't pull in the Prometheus client.
+package promcache
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	cache "github.com/uzqw/golang-lock-benchmarks"
+)
+
+type collector struct {
+	c       cache.Cache
+	size    *prometheus.Desc
+	hits    *prometheus.Desc
+	misses  *prometheus.Desc
+	updates *prometheus.Desc
+}
+
+// PrometheusCollector exports c's entry count as a gauge and its Stats as
+// counters, all under namespace. Metrics are read from the cache on every
+// scrape. Caches without Len or Stats simply omit those metrics.
+func PrometheusCollector(c cache.Cache, namespace string) prometheus.Collector {
+	return &collector{
+		c:       c,
+		size:    prometheus.NewDesc(prometheus.BuildFQName(namespace, "cache", "entries"), "Number of entries in the cache.", nil, nil),
+		hits:    prometheus.NewDesc(prometheus.BuildFQName(namespace, "cache", "hits_total"), "Gets that found a value.", nil, nil),
+		misses:  prometheus.NewDesc(prometheus.BuildFQName(namespace, "cache", "misses_total"), "Gets that returned nil.", nil, nil),
+		updates: prometheus.NewDesc(prometheus.BuildFQName(namespace, "cache", "updates_total"), "Values written to the cache.", nil, nil),
+	}
+}
+
+func (p *collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- p.size
+	ch <- p.hits
+	ch <- p.misses
+	ch <- p.updates
+}
+
+func (p *collector) Collect(ch chan<- prometheus.Metric) {
+	if c, ok := p.c.(interface{ Len() int }); ok {
+		ch <- prometheus.MustNewConstMetric(p.size, prometheus.GaugeValue, float64(c.Len()))
+	}
+	if c, ok := p.c.(interface{ Stats() cache.Stats }); ok {
+		s := c.Stats()
+		ch <- prometheus.MustNewConstMetric(p.hits, prometheus.CounterValue, float64(s.Hits))
+		ch <- prometheus.MustNewConstMetric(p.misses, prometheus.CounterValue, float64(s.Misses))
+		ch <- prometheus.MustNewConstMetric(p.updates, prometheus.CounterValue, float64(s.Updates))
+	}
+}
@@ -0,0 +1,63 @@
+package promcache
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	cache "github.com/uzqw/golang-lock-benchmarks"
+)
+
+func TestPrometheusCollector(t *testing.T) {
+	c := cache.NewShardedCache()
+	c.Update("disk-1", &cache.DiskStatus{ID: "disk-1"})
+	c.Update("disk-2", &cache.DiskStatus{ID: "disk-2"})
+	c.Get("disk-1")
+	c.Get("missing")
+
+	reg := prometheus.NewPedanticRegistry()
+	if err := reg.Register(PrometheusCollector(c, "disks")); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("gather: %v", err)
+	}
+
+	want := map[string]float64{
+		"disks_cache_entries":       2,
+		"disks_cache_hits_total":    1,
+		"disks_cache_misses_total":  1,
+		"disks_cache_updates_total": 2,
+	}
+	got := make(map[string]float64)
+	for _, mf := range families {
+		m := mf.GetMetric()[0]
+		if g := m.GetGauge(); g != nil {
+			got[mf.GetName()] = g.GetValue()
+		} else {
+			got[mf.GetName()] = m.GetCounter().GetValue()
+		}
+	}
+	for name, v := range want {
+		if gv, ok := got[name]; !ok || gv != v {
+			t.Errorf("%s: expected %v, got (%v, present %v)", name, v, gv, ok)
+		}
+	}
+}
+
+func TestPrometheusCollectorWithoutStats(t *testing.T) {
+	// LRUCache has Len but no Stats, so only the size gauge is exported
+	c := cache.NewLRUCache(10)
+	c.Update("disk-1", &cache.DiskStatus{ID: "disk-1"})
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(PrometheusCollector(c, "disks"))
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("gather: %v", err)
+	}
+	if len(families) != 1 || families[0].GetName() != "disks_cache_entries" {
+		t.Errorf("expected only disks_cache_entries, got %d families", len(families))
+	}
+}
@@ -0,0 +1,228 @@
+package cache
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// 17. Read-Copy-Update Cache
+//
+// Entries live in a persistent trie indexed by the key's hash: rcuDepth levels
+// of rcuFanout-way nodes above leaves holding the entries themselves. A write
+// copies only the path from the root to its leaf, about rcuDepth+1 small
+// nodes, and publishes the new root with one atomic store, so unlike COWCache
+// it never copies the whole map. Readers load the root and walk it without
+// locks. Writers are serialized by mu.
+//
+// Reclamation: the garbage collector would free replaced nodes on its own, so
+// epochs are used to recycle them instead, saving writers an allocation per
+// node. A reader pins the current epoch for the duration of its walk by
+// counting itself in readers[epoch&1]. Nodes a write replaces are retired into
+// limbo[epoch&1]. The epoch only advances from e to e+1 once no reader is
+// pinned at e-1 (the other parity), and at that point nodes retired during e-1
+// are unreachable: every reader that could have loaded a root containing them
+// pinned e-1 or earlier and has finished. They move to the free list for reuse.
+// A reader that stays pinned simply stops the epoch from advancing; writers
+// never wait for readers.
+type RCUCache struct {
+	counters
+	root atomic.Pointer[rcuNode]
+	n    atomic.Int64
+
+	epoch   atomic.Uint64
+	readers [2]atomic.Int64 // pinned readers, by epoch parity
+
+	mu       sync.Mutex    // serializes writers
+	limbo    [2][]*rcuNode // retired nodes, by the parity of the epoch they were retired in
+	free     []*rcuNode    // reclaimed nodes, reused by writers
+	recycled atomic.Uint64 // nodes taken from free, for tests
+}
+
+const (
+	rcuBits    = 4
+	rcuFanout  = 1 << rcuBits
+	rcuDepth   = 4    // interior levels, using 16 bits of the hash
+	rcuMaxFree = 1024 // nodes kept for reuse; the rest go to the GC
+)
+
+// rcuNode is an interior node (kids) or, at depth rcuDepth, a leaf (entries).
+// Published nodes are never modified until they are recycled.
+type rcuNode struct {
+	kids    [rcuFanout]*rcuNode
+	entries []rcuEntry
+}
+
+type rcuEntry struct {
+	id     string
+	status *DiskStatus
+}
+
+var _ Cache = (*RCUCache)(nil)
+
+func NewRCUCache() *RCUCache {
+	c := &RCUCache{}
+	c.root.Store(&rcuNode{})
+	return c
+}
+
+// pin registers a reader in the current epoch and returns it for unpin. The
+// recheck catches an epoch that advanced between the load and the count.
+func (c *RCUCache) pin() uint64 {
+	for {
+		e := c.epoch.Load()
+		c.readers[e&1].Add(1)
+		if c.epoch.Load() == e {
+			return e
+		}
+		c.readers[e&1].Add(-1)
+	}
+}
+
+func (c *RCUCache) unpin(e uint64) {
+	c.readers[e&1].Add(-1)
+}
+
+func rcuIndex(h uint32, level int) int {
+	return int(h>>(level*rcuBits)) & (rcuFanout - 1)
+}
+
+func (c *RCUCache) lookup(id string) *DiskStatus {
+	e := c.pin()
+	defer c.unpin(e)
+	h := fnv32a(id)
+	n := c.root.Load()
+	for level := 0; level < rcuDepth; level++ {
+		if n = n.kids[rcuIndex(h, level)]; n == nil {
+			return nil
+		}
+	}
+	for _, ent := range n.entries {
+		if ent.id == id {
+			return ent.status
+		}
+	}
+	return nil
+}
+
+func (c *RCUCache) Get(id string) *DiskStatus {
+	status := c.lookup(id)
+	c.recordGet(status)
+	return status
+}
+
+func (c *RCUCache) Contains(id string) bool {
+	return c.lookup(id) != nil
+}
+
+func (c *RCUCache) Update(id string, status *DiskStatus) {
+	if status == nil {
+		c.Delete(id)
+		return
+	}
+	c.recordUpdate()
+	c.write(id, status)
+}
+
+func (c *RCUCache) Delete(id string) {
+	c.write(id, nil)
+}
+
+func (c *RCUCache) Len() int {
+	return int(c.n.Load())
+}
+
+// write publishes a new root in which id maps to status, or is absent if
+// status is nil.
+func (c *RCUCache) write(id string, status *DiskStatus) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	h := fnv32a(id)
+	var path [rcuDepth + 1]*rcuNode // the current nodes from root to leaf, nil below a gap
+	path[0] = c.root.Load()
+	for level := 0; level < rcuDepth && path[level] != nil; level++ {
+		path[level+1] = path[level].kids[rcuIndex(h, level)]
+	}
+
+	var old []rcuEntry
+	if path[rcuDepth] != nil {
+		old = path[rcuDepth].entries
+	}
+	i := -1
+	for j, ent := range old {
+		if ent.id == id {
+			i = j
+			break
+		}
+	}
+	if i < 0 && status == nil {
+		return
+	}
+
+	var child *rcuNode
+	// Removing the last entry leaves no leaf at all
+	if status != nil || len(old) > 1 {
+		child = c.alloc()
+		for j, ent := range old {
+			if j != i {
+				child.entries = append(child.entries, ent)
+			}
+		}
+		if status != nil {
+			child.entries = append(child.entries, rcuEntry{id: id, status: status})
+		}
+	}
+	for level := rcuDepth - 1; level >= 0; level-- {
+		n := c.alloc()
+		if path[level] != nil {
+			n.kids = path[level].kids
+		}
+		n.kids[rcuIndex(h, level)] = child
+		child = n
+	}
+	c.root.Store(child)
+
+	switch {
+	case i < 0:
+		c.n.Add(1)
+	case status == nil:
+		c.n.Add(-1)
+	}
+	e := c.epoch.Load()
+	for _, n := range path {
+		if n != nil {
+			c.limbo[e&1] = append(c.limbo[e&1], n)
+		}
+	}
+	c.advance(e)
+}
+
+// advance moves the epoch past e if no reader is pinned at e-1, then recycles
+// the nodes retired during e-1. The caller must hold c.mu.
+func (c *RCUCache) advance(e uint64) {
+	next := (e + 1) & 1
+	if c.readers[next].Load() != 0 {
+		return
+	}
+	c.epoch.Store(e + 1)
+	for _, n := range c.limbo[next] {
+		if len(c.free) < rcuMaxFree {
+			clear(n.entries)
+			c.free = append(c.free, n)
+		}
+	}
+	clear(c.limbo[next])
+	c.limbo[next] = c.limbo[next][:0]
+}
+
+// alloc returns an empty node, recycled if possible. The caller must hold c.mu.
+func (c *RCUCache) alloc() *rcuNode {
+	if len(c.free) == 0 {
+		return &rcuNode{}
+	}
+	n := c.free[len(c.free)-1]
+	c.free = c.free[:len(c.free)-1]
+	c.recycled.Add(1)
+	*n = rcuNode{entries: n.entries[:0]}
+	return n
+}
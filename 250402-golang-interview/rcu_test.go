@@ -0,0 +1,132 @@
+package cache
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRCUCache(t *testing.T) {
+	t.Run("ConcurrentReadWrite", func(t *testing.T) {
+		const keys, writers, readers, perWriter = 256, 4, 16, 2000
+		c := NewRCUCache()
+
+		// Every value written has Health == Temp and the right ID, so a reader
+		// walking a recycled node would see the invariant break
+		var wg sync.WaitGroup
+		var stop atomic.Bool
+		for r := 0; r < readers; r++ {
+			wg.Add(1)
+			go func(r int) {
+				defer wg.Done()
+				for i := r; !stop.Load(); i++ {
+					id := fmt.Sprintf("disk-%d", i%keys)
+					if got := c.Get(id); got != nil && (got.ID != id || got.Health != got.Temp) {
+						t.Errorf("expected a consistent %s, got %+v", id, got)
+						return
+					}
+				}
+			}(r)
+		}
+
+		var writes sync.WaitGroup
+		for w := 0; w < writers; w++ {
+			writes.Add(1)
+			go func(w int) {
+				defer writes.Done()
+				for i := 0; i < perWriter; i++ {
+					id := fmt.Sprintf("disk-%d", (w*perWriter+i)%keys)
+					if i%5 == 4 {
+						c.Delete(id)
+						continue
+					}
+					c.Update(id, &DiskStatus{ID: id, Health: i, Temp: i})
+				}
+			}(w)
+		}
+		writes.Wait()
+		stop.Store(true)
+		wg.Wait()
+
+		live := 0
+		for i := 0; i < keys; i++ {
+			if c.Contains(fmt.Sprintf("disk-%d", i)) {
+				live++
+			}
+		}
+		if got := c.Len(); got != live {
+			t.Errorf("expected Len %d, got %d", live, got)
+		}
+	})
+
+	t.Run("RecyclesNodes", func(t *testing.T) {
+		c := NewRCUCache()
+		for i := 0; i < 100; i++ {
+			id := fmt.Sprintf("disk-%d", i%10)
+			c.Update(id, &DiskStatus{ID: id, Temp: i})
+		}
+		if c.recycled.Load() == 0 {
+			t.Errorf("expected retired nodes to be reused without readers pinned")
+		}
+		for i := 0; i < 10; i++ {
+			id := fmt.Sprintf("disk-%d", i)
+			if got := c.Get(id); got == nil || got.Temp != 90+i {
+				t.Errorf("expected %s with Temp %d, got %v", id, 90+i, got)
+			}
+		}
+	})
+
+	t.Run("PinnedReaderHoldsEpoch", func(t *testing.T) {
+		c := NewRCUCache()
+		status := &DiskStatus{ID: "disk-1"}
+		c.Update("disk-1", status)
+		e := c.pin()
+		root := c.root.Load()
+		before := c.recycled.Load()
+		for i := 0; i < 50; i++ {
+			c.Update("disk-1", &DiskStatus{ID: "disk-1", Temp: i})
+		}
+		// The epoch can move one step past the reader but no further, so
+		// nothing it may be walking is reused
+		if got := c.epoch.Load(); got > e+1 {
+			t.Errorf("expected the epoch held at %d, got %d", e+1, got)
+		}
+		n := root
+		for level := 0; level < rcuDepth; level++ {
+			n = n.kids[rcuIndex(fnv32a("disk-1"), level)]
+		}
+		if len(n.entries) != 1 || n.entries[0].status != status {
+			t.Errorf("expected the pinned root to still hold the original value, got %v", n.entries)
+		}
+		c.unpin(e)
+		c.Update("disk-1", &DiskStatus{ID: "disk-1"})
+		c.Update("disk-1", &DiskStatus{ID: "disk-1"})
+		if c.recycled.Load() == before {
+			t.Errorf("expected recycling to resume after unpin")
+		}
+	})
+}
+
+// Benchmark: writes to a full cache. COWCache copies all numKeys entries per
+// write; RCUCache copies one root-to-leaf path.
+func BenchmarkRCUWrite(b *testing.B) {
+	caches := []struct {
+		name string
+		new  func() Cache
+	}{
+		{"COW", func() Cache { return NewCOWCache() }},
+		{"RCU", func() Cache { return NewRCUCache() }},
+	}
+	for _, impl := range caches {
+		b.Run(impl.name, func(b *testing.B) {
+			c := initCache(impl.new())
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				id := fmt.Sprintf("disk-%d", i%numKeys)
+				c.Update(id, &DiskStatus{ID: id, Health: 100, Temp: 45})
+			}
+		})
+	}
+}
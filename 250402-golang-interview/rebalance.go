@@ -0,0 +1,26 @@
+package cache
+
+// DefaultRebalanceThreshold is how many contended shard lock acquisitions
+// between two MaybeRebalance calls make it double the shard count.
+const DefaultRebalanceThreshold = 1000
+
+// MaybeRebalance doubles the shard count, up to 1024, if enough shard lock
+// acquisitions had to wait since the previous call, and reports whether it
+// did. Callers invoke it periodically (e.g. from a ticker), which sets the
+// window contention is measured over; it resets the count either way. The
+// resize itself blocks every caller while entries are rehashed.
+func (c *ShardedCache) MaybeRebalance() bool {
+	threshold := c.rebalance
+	if threshold == 0 {
+		threshold = DefaultRebalanceThreshold
+	}
+	if c.base.contended.Swap(0) < threshold {
+		return false
+	}
+	n := len(c.base.table.Load().shards)
+	if n >= maxShardCount {
+		return false
+	}
+	c.ResizeShards(min(2*n, maxShardCount))
+	return true
+}
@@ -0,0 +1,50 @@
+package cache
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestShardedCacheMaybeRebalance(t *testing.T) {
+	const waiters = 20
+	c := NewShardedCacheWithShards(4)
+	c.rebalance = waiters
+	initCache(c)
+
+	if c.MaybeRebalance() {
+		t.Fatalf("expected no rebalance without contention")
+	}
+
+	// Hold disk-1's shard while readers pile up behind it
+	var wg sync.WaitGroup
+	c.WithShard("disk-1", func(map[string]*DiskStatus) {
+		for i := 0; i < waiters; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				c.Get("disk-1")
+			}()
+		}
+		for c.base.contended.Load() < waiters {
+			time.Sleep(time.Millisecond)
+		}
+	})
+	wg.Wait()
+
+	if !c.MaybeRebalance() {
+		t.Fatalf("expected a rebalance after %d contended reads", waiters)
+	}
+	if got := len(c.ShardSizes()); got != 8 {
+		t.Errorf("expected 8 shards, got %d", got)
+	}
+	for _, status := range prepareTestData() {
+		if got := c.Get(status.ID); got == nil || *got != *status {
+			t.Fatalf("expected %v to survive the resize, got %v", status, got)
+		}
+	}
+	// The window starts over
+	if c.MaybeRebalance() {
+		t.Errorf("expected the contention count to reset")
+	}
+}
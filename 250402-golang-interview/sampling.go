@@ -0,0 +1,42 @@
+package cache
+
+import (
+	"math"
+	"math/rand/v2"
+)
+
+// sampler picks Gets to report. It draws from math/rand/v2's global source,
+// which the runtime keeps per thread, so sampling adds no shared counter for
+// concurrent Gets to fight over.
+type sampler struct {
+	threshold uint64 // a draw below this is sampled
+	sink      func(id string)
+}
+
+// newSampler returns a sampler passing rate (0 to 1] of ids to sink.
+func newSampler(rate float64, sink func(id string)) *sampler {
+	threshold := uint64(math.MaxUint64)
+	if rate < 1 {
+		threshold = uint64(rate * math.MaxUint64)
+	}
+	return &sampler{threshold: threshold, sink: sink}
+}
+
+func (s *sampler) sample(id string) {
+	if rand.Uint64() < s.threshold {
+		s.sink(id)
+	}
+}
+
+// EnableSampling calls sink with the id of roughly rate (0 to 1) of all Gets,
+// hits and misses alike, for finding hot keys cheaply. sink runs on the
+// calling goroutine after the shard lock is released, so it should be fast
+// and may call back into the cache. A rate <= 0 or a nil sink turns sampling
+// off.
+func (c *ShardedCache) EnableSampling(rate float64, sink func(id string)) {
+	if rate <= 0 || sink == nil {
+		c.sampler.Store(nil)
+		return
+	}
+	c.sampler.Store(newSampler(rate, sink))
+}
@@ -0,0 +1,33 @@
+package cache
+
+import (
+	"math"
+	"sync/atomic"
+	"testing"
+)
+
+func TestShardedCacheSampling(t *testing.T) {
+	const gets, rate = 100000, 0.01
+	c := NewShardedCache()
+	initCache(c)
+
+	var sampled atomic.Int64
+	c.EnableSampling(rate, func(id string) { sampled.Add(1) })
+	for i := 0; i < gets; i++ {
+		c.Get("disk-1")
+	}
+
+	// Binomial(gets, rate): allow 5 standard deviations either side
+	mean := rate * gets
+	slack := 5 * math.Sqrt(gets*rate*(1-rate))
+	if got := float64(sampled.Load()); got < mean-slack || got > mean+slack {
+		t.Errorf("expected about %.0f samples (±%.0f), got %.0f", mean, slack, got)
+	}
+
+	c.EnableSampling(0, nil)
+	before := sampled.Load()
+	c.Get("disk-1")
+	if got := sampled.Load(); got != before {
+		t.Errorf("expected no samples once disabled, got %d", got-before)
+	}
+}
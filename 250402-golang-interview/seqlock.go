@@ -0,0 +1,161 @@
+package cache
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// 11. Seqlock Cache
+//
+// Entries hold DiskStatus by value behind a sequence counter. A writer makes
+// the counter odd, stores the fields, then makes it even again; a reader
+// retries until it sees the same even counter before and after copying the
+// fields, so it never takes a lock and never returns a torn struct. Fields are
+// stored atomically so the racing reads stay within the memory model.
+//
+// The key index is copy-on-write: only inserting or removing a key copies
+// it, while overwriting an existing key just bumps that entry's sequence.
+type SeqLockCache struct {
+	counters
+	mu    sync.Mutex // serializes writers
+	index atomic.Pointer[map[string]*seqEntry]
+}
+
+type seqEntry struct {
+	seq    atomic.Uint64
+	id     atomic.Pointer[string]
+	health atomic.Int64
+	temp   atomic.Int64
+}
+
+var _ Cache = (*SeqLockCache)(nil)
+
+func NewSeqLockCache() *SeqLockCache {
+	c := &SeqLockCache{}
+	m := make(map[string]*seqEntry)
+	c.index.Store(&m)
+	return c
+}
+
+// load copies the entry's fields, retrying while a write is in progress.
+func (e *seqEntry) load() *DiskStatus {
+	for {
+		seq := e.seq.Load()
+		if seq&1 == 0 {
+			status := &DiskStatus{
+				ID:     *e.id.Load(),
+				Health: int(e.health.Load()),
+				Temp:   int(e.temp.Load()),
+			}
+			if e.seq.Load() == seq {
+				return status
+			}
+		}
+		runtime.Gosched()
+	}
+}
+
+// store writes status into the entry. The caller must hold the cache's mu.
+func (e *seqEntry) store(status *DiskStatus) {
+	e.seq.Add(1)
+	// Point at a copy, not into the caller's struct, which they may change
+	id := status.ID
+	e.id.Store(&id)
+	e.health.Store(int64(status.Health))
+	e.temp.Store(int64(status.Temp))
+	e.seq.Add(1)
+}
+
+// Get returns a private copy of the stored value, since entries aren't
+// pointers that can be shared.
+func (c *SeqLockCache) Get(id string) *DiskStatus {
+	e := (*c.index.Load())[id]
+	if e == nil {
+		c.recordGet(nil)
+		return nil
+	}
+	status := e.load()
+	c.recordGet(status)
+	return status
+}
+
+// GetCopy is Get; every Get already returns a copy.
+func (c *SeqLockCache) GetCopy(id string) *DiskStatus {
+	return c.Get(id)
+}
+
+func (c *SeqLockCache) Contains(id string) bool {
+	_, ok := (*c.index.Load())[id]
+	return ok
+}
+
+// Update copies *status into the cache. A nil status can't be stored by value,
+// so it deletes id instead.
+func (c *SeqLockCache) Update(id string, status *DiskStatus) {
+	if status == nil {
+		c.Delete(id)
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.recordUpdate()
+	old := *c.index.Load()
+	if e := old[id]; e != nil {
+		e.store(status)
+		return
+	}
+	e := &seqEntry{}
+	e.store(status)
+	new := make(map[string]*seqEntry, len(old)+1)
+	for k, v := range old {
+		new[k] = v
+	}
+	new[id] = e
+	c.index.Store(&new)
+}
+
+func (c *SeqLockCache) Delete(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	old := *c.index.Load()
+	if _, ok := old[id]; !ok {
+		return
+	}
+	new := make(map[string]*seqEntry, len(old))
+	for k, v := range old {
+		if k != id {
+			new[k] = v
+		}
+	}
+	c.index.Store(&new)
+}
+
+func (c *SeqLockCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	m := make(map[string]*seqEntry)
+	c.index.Store(&m)
+}
+
+func (c *SeqLockCache) Len() int {
+	return len(*c.index.Load())
+}
+
+func (c *SeqLockCache) Keys() []string {
+	m := *c.index.Load()
+	keys := make([]string, 0, len(m))
+	for id := range m {
+		keys = append(keys, id)
+	}
+	return keys
+}
+
+func (c *SeqLockCache) Snapshot() map[string]*DiskStatus {
+	m := *c.index.Load()
+	snap := make(map[string]*DiskStatus, len(m))
+	for id, e := range m {
+		snap[id] = e.load()
+	}
+	return snap
+}
@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSeqLockCacheNoTornReads(t *testing.T) {
+	const (
+		writers = 4
+		readers = 8
+		writes  = 2000
+	)
+	c := NewSeqLockCache()
+	c.Update("disk-1", &DiskStatus{ID: "disk-1"})
+
+	// Every write keeps Health == Temp and encodes the same value in ID, so a
+	// mix of two writes shows up as a mismatch
+	var stop atomic.Bool
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < writes; i++ {
+				v := w*writes + i
+				c.Update("disk-1", &DiskStatus{ID: fmt.Sprintf("disk-1@%d", v), Health: v, Temp: v})
+			}
+		}(w)
+	}
+
+	var torn atomic.Int64
+	var rwg sync.WaitGroup
+	for r := 0; r < readers; r++ {
+		rwg.Add(1)
+		go func() {
+			defer rwg.Done()
+			for !stop.Load() {
+				got := c.Get("disk-1")
+				if got.Health != got.Temp || (got.Health != 0 && got.ID != fmt.Sprintf("disk-1@%d", got.Health)) {
+					torn.Add(1)
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	stop.Store(true)
+	rwg.Wait()
+	if n := torn.Load(); n != 0 {
+		t.Errorf("expected no torn reads, got %d", n)
+	}
+}
+
+func TestSeqLockCacheStoresValues(t *testing.T) {
+	c := NewSeqLockCache()
+	status := &DiskStatus{ID: "disk-1", Temp: 40}
+	c.Update("disk-1", status)
+
+	// Later changes to the caller's struct must not leak into the cache
+	status.Temp = 90
+	status.ID = "disk-2"
+	if got := c.Get("disk-1"); got.Temp != 40 || got.ID != "disk-1" {
+		t.Errorf("expected stored {disk-1 40}, got %v", got)
+	}
+
+	c.Update("disk-1", nil)
+	if c.Contains("disk-1") {
+		t.Errorf("expected nil Update to delete disk-1")
+	}
+}
@@ -0,0 +1,149 @@
+package cache
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// 15. Sharded Copy-on-Write Cache
+//
+// COWCache split into ShardCount independent copy-on-write maps. Reads stay
+// lock-free, while a write copies only its own shard, about 1/32 of the
+// entries, and only contends with writers to the same shard.
+type ShardedCOWCache struct {
+	counters
+	shards [ShardCount]cowShard
+
+	copies        atomic.Uint64
+	copiedEntries atomic.Uint64
+}
+
+type cowShard struct {
+	mu    sync.Mutex   // serializes writers; readers never take it
+	disks atomic.Value // stores map[string]*DiskStatus
+}
+
+var _ Cache = (*ShardedCOWCache)(nil)
+
+func NewShardedCOWCache() *ShardedCOWCache {
+	c := &ShardedCOWCache{}
+	for i := range c.shards {
+		c.shards[i].disks.Store(make(map[string]*DiskStatus))
+	}
+	return c
+}
+
+func (c *ShardedCOWCache) shard(id string) *cowShard {
+	return &c.shards[fnv32a(id)%ShardCount]
+}
+
+func (s *cowShard) load() map[string]*DiskStatus {
+	return s.disks.Load().(map[string]*DiskStatus)
+}
+
+func (c *ShardedCOWCache) Get(id string) *DiskStatus {
+	status := c.shard(id).load()[id]
+	c.recordGet(status)
+	return status
+}
+
+func (c *ShardedCOWCache) Contains(id string) bool {
+	_, ok := c.shard(id).load()[id]
+	return ok
+}
+
+// GetCopy is Get returning a private copy, so mutating it can't corrupt the
+// shared cached value.
+func (c *ShardedCOWCache) GetCopy(id string) *DiskStatus {
+	return cloneStatus(c.Get(id))
+}
+
+func (c *ShardedCOWCache) Update(id string, status *DiskStatus) {
+	if status == nil {
+		c.Delete(id)
+		return
+	}
+	s := c.shard(id)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c.recordUpdate()
+	old := s.load()
+	new := make(map[string]*DiskStatus, len(old)+1)
+	for k, v := range old {
+		new[k] = v
+	}
+	new[id] = status
+	s.disks.Store(new)
+	c.recordCopy(len(old))
+}
+
+func (c *ShardedCOWCache) recordCopy(entries int) {
+	c.copies.Add(1)
+	c.copiedEntries.Add(uint64(entries))
+}
+
+// Stats reports copy cost like COWCache.Stats.
+func (c *ShardedCOWCache) Stats() Stats {
+	s := c.counters.Stats()
+	s.Copies = c.copies.Load()
+	s.CopiedEntries = c.copiedEntries.Load()
+	return s
+}
+
+func (c *ShardedCOWCache) Delete(id string) {
+	s := c.shard(id)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old := s.load()
+	if _, ok := old[id]; !ok {
+		return
+	}
+	new := make(map[string]*DiskStatus, len(old))
+	for k, v := range old {
+		if k != id {
+			new[k] = v
+		}
+	}
+	s.disks.Store(new)
+	c.recordCopy(len(old) - 1)
+}
+
+// Clear empties one shard at a time.
+func (c *ShardedCOWCache) Clear() {
+	for i := range c.shards {
+		s := &c.shards[i]
+		s.mu.Lock()
+		s.disks.Store(make(map[string]*DiskStatus))
+		s.mu.Unlock()
+	}
+}
+
+func (c *ShardedCOWCache) Len() int {
+	n := 0
+	for i := range c.shards {
+		n += len(c.shards[i].load())
+	}
+	return n
+}
+
+func (c *ShardedCOWCache) Keys() []string {
+	var keys []string
+	for i := range c.shards {
+		for id := range c.shards[i].load() {
+			keys = append(keys, id)
+		}
+	}
+	return keys
+}
+
+// Snapshot copies each shard's immutable map in turn, so it is consistent per
+// shard but not across shards.
+func (c *ShardedCOWCache) Snapshot() map[string]*DiskStatus {
+	snap := make(map[string]*DiskStatus)
+	for i := range c.shards {
+		for id, status := range c.shards[i].load() {
+			snap[id] = cloneStatus(status)
+		}
+	}
+	return snap
+}
@@ -0,0 +1,49 @@
+package cache
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestShardedCOWCache(t *testing.T) {
+	t.Run("NoLostWrites", func(t *testing.T) {
+		const writers, perWriter = 16, 200
+		c := NewShardedCOWCache()
+		var wg sync.WaitGroup
+		for w := 0; w < writers; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				for i := 0; i < perWriter; i++ {
+					id := fmt.Sprintf("disk-%d-%d", w, i)
+					c.Update(id, &DiskStatus{ID: id, Temp: w})
+				}
+			}(w)
+		}
+		wg.Wait()
+
+		if got := c.Len(); got != writers*perWriter {
+			t.Fatalf("expected %d entries, got %d", writers*perWriter, got)
+		}
+		for w := 0; w < writers; w++ {
+			for i := 0; i < perWriter; i++ {
+				id := fmt.Sprintf("disk-%d-%d", w, i)
+				if got := c.Get(id); got == nil || got.Temp != w {
+					t.Fatalf("expected %s written by %d, got %v", id, w, got)
+				}
+			}
+		}
+	})
+
+	t.Run("CopiesOneShard", func(t *testing.T) {
+		c := NewShardedCOWCache()
+		initCache(c)
+		before := c.Stats().CopiedEntries
+		c.Update("disk-0", &DiskStatus{ID: "disk-0", Temp: 50})
+		want := uint64(len(c.shard("disk-0").load()))
+		if got := c.Stats().CopiedEntries - before; got != want {
+			t.Errorf("expected to copy only disk-0's shard (%d entries), got %d", want, got)
+		}
+	})
+}
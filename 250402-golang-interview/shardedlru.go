@@ -0,0 +1,59 @@
+package cache
+
+// ShardedLRUCache stripes keys over independent bounded LRUCaches, so Gets on
+// different shards don't serialize on one list lock. Each shard holds
+// maxEntries/n entries; recency is per shard, so the total is only bounded
+// approximately: an uneven key spread can evict from a full shard while
+// others still have room.
+type ShardedLRUCache struct {
+	shards []*LRUCache
+}
+
+var _ Cache = (*ShardedLRUCache)(nil)
+
+// NewShardedLRUCache returns a cache of n shards (n <= 0 falls back to
+// ShardCount) sharing maxEntries between them, at least one entry each.
+// maxEntries <= 0 means no limit.
+func NewShardedLRUCache(n, maxEntries int) *ShardedLRUCache {
+	if n <= 0 {
+		n = ShardCount
+	}
+	perShard := 0
+	if maxEntries > 0 {
+		perShard = max(maxEntries/n, 1)
+	}
+	c := &ShardedLRUCache{shards: make([]*LRUCache, n)}
+	for i := range c.shards {
+		c.shards[i] = NewLRUCache(perShard)
+	}
+	return c
+}
+
+func (c *ShardedLRUCache) getShard(id string) *LRUCache {
+	return c.shards[fnv32a(id)%uint32(len(c.shards))]
+}
+
+func (c *ShardedLRUCache) Get(id string) *DiskStatus {
+	return c.getShard(id).Get(id)
+}
+
+func (c *ShardedLRUCache) Update(id string, status *DiskStatus) {
+	c.getShard(id).Update(id, status)
+}
+
+// Contains reports presence without counting as an access.
+func (c *ShardedLRUCache) Contains(id string) bool {
+	return c.getShard(id).Contains(id)
+}
+
+func (c *ShardedLRUCache) Delete(id string) {
+	c.getShard(id).Delete(id)
+}
+
+func (c *ShardedLRUCache) Len() int {
+	n := 0
+	for _, shard := range c.shards {
+		n += shard.Len()
+	}
+	return n
+}
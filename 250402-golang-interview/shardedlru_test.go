@@ -0,0 +1,53 @@
+package cache
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestShardedLRUCache(t *testing.T) {
+	t.Run("BoundedUnderConcurrentInserts", func(t *testing.T) {
+		const shards, maxEntries, writers, perWriter = 8, 256, 8, 2000
+		c := NewShardedLRUCache(shards, maxEntries)
+
+		var wg sync.WaitGroup
+		for w := 0; w < writers; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				for i := 0; i < perWriter; i++ {
+					id := fmt.Sprintf("disk-%d-%d", w, i)
+					c.Update(id, &DiskStatus{ID: id})
+				}
+			}(w)
+		}
+		wg.Wait()
+
+		// Each shard fills to exactly its own share with this many keys
+		if got := c.Len(); got != maxEntries {
+			t.Errorf("expected Len %d, got %d", maxEntries, got)
+		}
+		for i, shard := range c.shards {
+			if got := shard.Len(); got != maxEntries/shards {
+				t.Errorf("shard %d: expected %d entries, got %d", i, maxEntries/shards, got)
+			}
+		}
+	})
+
+	t.Run("RecencyPerShard", func(t *testing.T) {
+		c := NewShardedLRUCache(4, 4) // one entry per shard
+		c.Update("disk-a", &DiskStatus{ID: "disk-a"})
+		// Find another id in the same shard; it displaces disk-a
+		var other string
+		for i := 0; other == ""; i++ {
+			if id := fmt.Sprintf("disk-%d", i); c.getShard(id) == c.getShard("disk-a") {
+				other = id
+			}
+		}
+		c.Update(other, &DiskStatus{ID: other})
+		if c.Contains("disk-a") || !c.Contains(other) {
+			t.Errorf("expected %s to evict disk-a from their shared shard", other)
+		}
+	})
+}
@@ -0,0 +1,146 @@
+package cache
+
+import (
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// SpilloverCache keeps up to maxEntries recently used entries in an LRUCache
+// and spills the ones it evicts to one gob file per key under dir. A miss in
+// memory checks dir and promotes the entry back, which may spill another.
+//
+// One mutex serializes every call, so the memory tier and the files never
+// disagree; it trades concurrency for bounded memory. Disk errors can't be
+// returned through the Cache methods: a failed spill drops the entry, and the
+// latest error is kept for Err.
+type SpilloverCache struct {
+	dir string
+
+	mu       sync.Mutex
+	mem      *LRUCache
+	onDisk   map[string]struct{}
+	err      error
+	deleting bool // set around mem.Delete so spill skips the removed entry
+}
+
+var _ Cache = (*SpilloverCache)(nil)
+
+// NewSpilloverCache creates dir if needed. Files already in it are ignored
+// and may be overwritten.
+func NewSpilloverCache(dir string, maxEntries int) (*SpilloverCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("cache: spillover dir: %w", err)
+	}
+	c := &SpilloverCache{
+		dir:    dir,
+		mem:    NewLRUCache(maxEntries),
+		onDisk: make(map[string]struct{}),
+	}
+	// Runs synchronously inside c.mem calls, so c.mu is already held
+	c.mem.OnEvict(c.spill)
+	return c, nil
+}
+
+// path hex-encodes id so any id is a safe file name.
+func (c *SpilloverCache) path(id string) string {
+	return filepath.Join(c.dir, hex.EncodeToString([]byte(id))+".gob")
+}
+
+func (c *SpilloverCache) spill(id string, status *DiskStatus) {
+	if c.deleting {
+		return
+	}
+	f, err := os.Create(c.path(id))
+	if err == nil {
+		err = gob.NewEncoder(f).Encode(status)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		c.err = fmt.Errorf("cache: spill %s: %w", id, err)
+		os.Remove(c.path(id))
+		return
+	}
+	c.onDisk[id] = struct{}{}
+}
+
+// unspill removes id's file, returning its decoded value if it had one.
+func (c *SpilloverCache) unspill(id string, decode bool) *DiskStatus {
+	if _, ok := c.onDisk[id]; !ok {
+		return nil
+	}
+	delete(c.onDisk, id)
+	path := c.path(id)
+	defer os.Remove(path)
+	if !decode {
+		return nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		c.err = fmt.Errorf("cache: load spilled %s: %w", id, err)
+		return nil
+	}
+	defer f.Close()
+	var status DiskStatus
+	if err := gob.NewDecoder(f).Decode(&status); err != nil {
+		c.err = fmt.Errorf("cache: load spilled %s: %w", id, err)
+		return nil
+	}
+	return &status
+}
+
+func (c *SpilloverCache) Get(id string) *DiskStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if status := c.mem.Get(id); status != nil {
+		return status
+	}
+	status := c.unspill(id, true)
+	if status != nil {
+		c.mem.Update(id, status)
+	}
+	return status
+}
+
+// Update stores status in memory, dropping any spilled copy. A nil status
+// deletes id.
+func (c *SpilloverCache) Update(id string, status *DiskStatus) {
+	if status == nil {
+		c.Delete(id)
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.unspill(id, false)
+	c.mem.Update(id, status)
+}
+
+func (c *SpilloverCache) Delete(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// mem.Delete reports the entry to spill like an eviction; an explicit
+	// delete must not write it to disk
+	c.deleting = true
+	c.mem.Delete(id)
+	c.deleting = false
+	c.unspill(id, false)
+}
+
+// Len counts entries in memory and on disk.
+func (c *SpilloverCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.mem.Len() + len(c.onDisk)
+}
+
+// Err returns the most recent disk error, or nil.
+func (c *SpilloverCache) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestSpilloverCache(t *testing.T) {
+	const maxEntries = 3
+	c, err := NewSpilloverCache(t.TempDir(), maxEntries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	onDisk := func(id string) bool {
+		_, err := os.Stat(c.path(id))
+		return err == nil
+	}
+
+	for i := 0; i < maxEntries+2; i++ {
+		id := fmt.Sprintf("disk-%d", i)
+		c.Update(id, &DiskStatus{ID: id, Health: 100 - i, Temp: 40 + i})
+	}
+	if !onDisk("disk-0") || !onDisk("disk-1") || onDisk("disk-4") {
+		t.Fatalf("expected the two oldest entries spilled to disk")
+	}
+	if got := c.Len(); got != maxEntries+2 {
+		t.Errorf("expected Len %d, got %d", maxEntries+2, got)
+	}
+
+	got := c.Get("disk-0")
+	if want := (DiskStatus{ID: "disk-0", Health: 100, Temp: 40}); got == nil || *got != want {
+		t.Fatalf("expected %v from disk, got %v", want, got)
+	}
+	if onDisk("disk-0") || !c.mem.Contains("disk-0") {
+		t.Errorf("expected disk-0 promoted back to memory")
+	}
+	// Promotion made room by spilling the least recently used entry
+	if !onDisk("disk-2") || c.mem.Len() != maxEntries {
+		t.Errorf("expected disk-2 spilled to keep %d entries in memory", maxEntries)
+	}
+
+	c.Delete("disk-1")
+	c.Delete("disk-0")
+	if onDisk("disk-1") || onDisk("disk-0") || c.Get("disk-0") != nil || c.Get("disk-1") != nil {
+		t.Errorf("expected Delete to remove both tiers")
+	}
+
+	// A nil update must not spill the old value like an eviction would
+	c.Update("disk-3", nil)
+	if c.Get("disk-3") != nil || onDisk("disk-3") {
+		t.Errorf("expected Update(nil) to delete disk-3")
+	}
+	if got := c.Len(); got != 2 {
+		t.Errorf("expected Len 2, got %d", got)
+	}
+	if err := c.Err(); err != nil {
+		t.Errorf("unexpected disk error: %v", err)
+	}
+
+	// With the directory gone any spill fails, so a clean Err shows Delete
+	// didn't try to write the in-memory entry
+	c.Update("disk-9", &DiskStatus{ID: "disk-9"})
+	if err := os.RemoveAll(c.dir); err != nil {
+		t.Fatal(err)
+	}
+	c.Delete("disk-9")
+	if err := c.Err(); err != nil {
+		t.Errorf("expected Delete not to spill, got %v", err)
+	}
+}
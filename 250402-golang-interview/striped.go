@@ -0,0 +1,107 @@
+package cache
+
+import "sync"
+
+// 10. Striped sync.Map Cache
+//
+// ShardCount sync.Maps selected by the same fnv shard function as
+// ShardedCache. Each stripe keeps sync.Map's lock-free read path while writes
+// that miss the read-only map only contend on their own stripe's mutex.
+type StripedSyncMapCache struct {
+	counters
+	stripes [ShardCount]sync.Map
+}
+
+var _ Cache = (*StripedSyncMapCache)(nil)
+
+func NewStripedSyncMapCache() *StripedSyncMapCache {
+	return &StripedSyncMapCache{}
+}
+
+func (c *StripedSyncMapCache) stripe(id string) *sync.Map {
+	return &c.stripes[fnv32a(id)%ShardCount]
+}
+
+func (c *StripedSyncMapCache) Get(id string) *DiskStatus {
+	v, ok := c.stripe(id).Load(id)
+	if !ok {
+		c.recordGet(nil)
+		return nil
+	}
+	status := v.(*DiskStatus)
+	c.recordGet(status)
+	return status
+}
+
+func (c *StripedSyncMapCache) Contains(id string) bool {
+	_, ok := c.stripe(id).Load(id)
+	return ok
+}
+
+// GetCopy is Get returning a private copy, so mutating it can't corrupt the
+// shared cached value.
+func (c *StripedSyncMapCache) GetCopy(id string) *DiskStatus {
+	return cloneStatus(c.Get(id))
+}
+
+func (c *StripedSyncMapCache) Update(id string, status *DiskStatus) {
+	if status == nil {
+		c.Delete(id)
+		return
+	}
+	c.recordUpdate()
+	c.stripe(id).Store(id, status)
+}
+
+func (c *StripedSyncMapCache) Delete(id string) {
+	c.stripe(id).Delete(id)
+}
+
+func (c *StripedSyncMapCache) Clear() {
+	for i := range c.stripes {
+		c.stripes[i].Clear()
+	}
+}
+
+// Len walks every stripe, since sync.Map doesn't track its size
+func (c *StripedSyncMapCache) Len() int {
+	n := 0
+	c.rangeAll(func(_ string, _ *DiskStatus) bool {
+		n++
+		return true
+	})
+	return n
+}
+
+func (c *StripedSyncMapCache) Keys() []string {
+	var keys []string
+	c.rangeAll(func(id string, _ *DiskStatus) bool {
+		keys = append(keys, id)
+		return true
+	})
+	return keys
+}
+
+// Snapshot copies all entries, values included. Like SyncMapCache.Snapshot,
+// it may or may not observe writes that race with it.
+func (c *StripedSyncMapCache) Snapshot() map[string]*DiskStatus {
+	snap := make(map[string]*DiskStatus)
+	c.rangeAll(func(id string, status *DiskStatus) bool {
+		snap[id] = cloneStatus(status)
+		return true
+	})
+	return snap
+}
+
+func (c *StripedSyncMapCache) rangeAll(fn func(id string, status *DiskStatus) bool) {
+	for i := range c.stripes {
+		cont := true
+		c.stripes[i].Range(func(k, v any) bool {
+			cont = fn(k.(string), v.(*DiskStatus))
+			return cont
+		})
+		if !cont {
+			return
+		}
+	}
+}
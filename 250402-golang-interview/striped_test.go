@@ -0,0 +1,36 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestStripedSyncMapCacheStripes(t *testing.T) {
+	c := initCache(NewStripedSyncMapCache()).(*StripedSyncMapCache)
+
+	// Every key must live in the stripe its id hashes to
+	used := 0
+	for i := range c.stripes {
+		n := 0
+		c.stripes[i].Range(func(k, _ any) bool {
+			if got := c.stripe(k.(string)); got != &c.stripes[i] {
+				t.Errorf("%s stored in stripe %d, not its hashed stripe", k, i)
+			}
+			n++
+			return true
+		})
+		if n > 0 {
+			used++
+		}
+	}
+	if used != ShardCount {
+		t.Errorf("expected %d keys to spread over all %d stripes, got %d", numKeys, ShardCount, used)
+	}
+
+	for i := 0; i < numKeys; i += 2 {
+		c.Delete(fmt.Sprintf("disk-%d", i))
+	}
+	if got := c.Len(); got != numKeys/2 {
+		t.Errorf("expected Len %d, got %d", numKeys/2, got)
+	}
+}
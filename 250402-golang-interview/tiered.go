@@ -0,0 +1,51 @@
+package cache
+
+import "sync"
+
+// TieredCache puts a small fast L1 in front of a larger L2. Get falls back to
+// L2 on an L1 miss and copies the hit into L1; Update writes through to both,
+// so L1 never holds a value L2 doesn't.
+//
+// A promotion and an Update of the same id take the same striped lock, so a
+// Get can't copy a value into L1 after an Update has replaced it in both
+// tiers.
+type TieredCache struct {
+	L1, L2 Cache
+
+	locks [ShardCount]sync.Mutex
+}
+
+var _ Cache = (*TieredCache)(nil)
+
+func NewTieredCache(l1, l2 Cache) *TieredCache {
+	return &TieredCache{L1: l1, L2: l2}
+}
+
+func (c *TieredCache) lock(id string) *sync.Mutex {
+	return &c.locks[fnv32a(id)%ShardCount]
+}
+
+func (c *TieredCache) Get(id string) *DiskStatus {
+	if status := c.L1.Get(id); status != nil {
+		return status
+	}
+	// L2 is read under the lock, so no Update can land between the read and
+	// the copy into L1
+	mu := c.lock(id)
+	mu.Lock()
+	defer mu.Unlock()
+	status := c.L2.Get(id)
+	if status != nil {
+		c.L1.Update(id, status)
+	}
+	return status
+}
+
+// Update writes L2 first, then L1, under id's lock.
+func (c *TieredCache) Update(id string, status *DiskStatus) {
+	mu := c.lock(id)
+	mu.Lock()
+	defer mu.Unlock()
+	c.L2.Update(id, status)
+	c.L1.Update(id, status)
+}
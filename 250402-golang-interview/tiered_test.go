@@ -0,0 +1,85 @@
+package cache
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// pausingCache blocks its first Get after reading, until release is closed
+type pausingCache struct {
+	Cache
+	once    sync.Once
+	read    chan struct{}
+	release chan struct{}
+}
+
+func newPausingCache(c Cache) *pausingCache {
+	return &pausingCache{Cache: c, read: make(chan struct{}), release: make(chan struct{})}
+}
+
+func (p *pausingCache) Get(id string) *DiskStatus {
+	status := p.Cache.Get(id)
+	p.once.Do(func() {
+		close(p.read)
+		<-p.release
+	})
+	return status
+}
+
+func TestTieredCache(t *testing.T) {
+	l1 := NewMutexCache()
+	l2 := NewShardedCache()
+	c := NewTieredCache(l1, l2)
+
+	t.Run("L2HitPopulatesL1", func(t *testing.T) {
+		l2.Update("disk-1", &DiskStatus{ID: "disk-1", Temp: 40})
+		if got := c.Get("disk-1"); got == nil || got.Temp != 40 {
+			t.Fatalf("expected disk-1 from L2, got %v", got)
+		}
+		if got := l1.Get("disk-1"); got == nil || got.Temp != 40 {
+			t.Errorf("expected disk-1 promoted into L1, got %v", got)
+		}
+	})
+
+	t.Run("UpdateWritesThrough", func(t *testing.T) {
+		c.Update("disk-2", &DiskStatus{ID: "disk-2"})
+		if l1.Get("disk-2") == nil || l2.Get("disk-2") == nil {
+			t.Errorf("expected disk-2 in both tiers")
+		}
+	})
+
+	// The Get reads the old value from L2 while an Update replaces it; the
+	// Get must not copy the old value into L1 afterwards
+	t.Run("NoStalePromotion", func(t *testing.T) {
+		l1, l2 := NewMutexCache(), newPausingCache(NewMutexCache())
+		c := NewTieredCache(l1, l2)
+		l2.Cache.Update("disk-1", &DiskStatus{ID: "disk-1", Temp: 40})
+
+		got := make(chan *DiskStatus)
+		go func() { got <- c.Get("disk-1") }()
+		<-l2.read
+		updated := make(chan struct{})
+		latest := &DiskStatus{ID: "disk-1", Temp: 50}
+		go func() {
+			c.Update("disk-1", latest)
+			close(updated)
+		}()
+		time.Sleep(10 * time.Millisecond)
+		close(l2.release)
+		<-got
+		<-updated
+		if status := l1.Get("disk-1"); status != latest {
+			t.Errorf("expected L1 to hold the latest value, got %v", status)
+		}
+	})
+
+	t.Run("MissInBoth", func(t *testing.T) {
+		if got := c.Get("missing"); got != nil {
+			t.Errorf("expected nil, got %v", got)
+		}
+		if l1.Contains("missing") {
+			t.Errorf("expected a miss not to populate L1")
+		}
+	})
+}
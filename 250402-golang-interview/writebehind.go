@@ -0,0 +1,109 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+// WriteBehindCache smooths write bursts to a slow backing store. Update writes
+// the wrapped cache immediately and marks the id dirty; a background goroutine
+// hands all dirty entries to flush in one batch every interval. Repeated
+// writes to an id between flushes collapse into its latest value.
+type WriteBehindCache struct {
+	cache Cache
+	flush func(map[string]*DiskStatus) error
+
+	mu    sync.Mutex // orders writes to cache with their dirty entries
+	dirty map[string]*DiskStatus
+
+	flushMu sync.Mutex // keeps batches in order
+
+	done   chan struct{}
+	exited chan struct{}
+	once   sync.Once
+}
+
+var _ Cache = (*WriteBehindCache)(nil)
+
+// NewWriteBehindCache starts the background flusher. Call Close to stop it.
+func NewWriteBehindCache(c Cache, flush func(map[string]*DiskStatus) error, interval time.Duration) *WriteBehindCache {
+	wb := &WriteBehindCache{
+		cache:  c,
+		flush:  flush,
+		dirty:  make(map[string]*DiskStatus),
+		done:   make(chan struct{}),
+		exited: make(chan struct{}),
+	}
+	go wb.loop(interval)
+	return wb
+}
+
+func (c *WriteBehindCache) loop(interval time.Duration) {
+	defer close(c.exited)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			// A failed batch is re-queued, so the next tick retries it
+			c.Flush()
+		case <-c.done:
+			return
+		}
+	}
+}
+
+func (c *WriteBehindCache) Get(id string) *DiskStatus {
+	return c.cache.Get(id)
+}
+
+// Update writes the wrapped cache and marks id dirty as one step, so racing
+// writers leave the same value in both. A nil status deletes id there and
+// reaches flush as a nil value, for the store to delete too.
+func (c *WriteBehindCache) Update(id string, status *DiskStatus) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cache.Update(id, status)
+	c.dirty[id] = status
+}
+
+// Delete is Update with a nil status.
+func (c *WriteBehindCache) Delete(id string) {
+	c.Update(id, nil)
+}
+
+// Flush hands the current dirty set to flush, if there is one. If flush fails
+// the batch is re-queued, except for ids written again in the meantime.
+func (c *WriteBehindCache) Flush() error {
+	c.flushMu.Lock()
+	defer c.flushMu.Unlock()
+
+	c.mu.Lock()
+	batch := c.dirty
+	if len(batch) == 0 {
+		c.mu.Unlock()
+		return nil
+	}
+	c.dirty = make(map[string]*DiskStatus)
+	c.mu.Unlock()
+
+	err := c.flush(batch)
+	if err != nil {
+		c.mu.Lock()
+		for id, status := range batch {
+			if _, ok := c.dirty[id]; !ok {
+				c.dirty[id] = status
+			}
+		}
+		c.mu.Unlock()
+	}
+	return err
+}
+
+// Close stops the background flusher and flushes whatever is still dirty.
+// It is safe to call more than once.
+func (c *WriteBehindCache) Close() error {
+	c.once.Do(func() { close(c.done) })
+	<-c.exited
+	return c.Flush()
+}
@@ -0,0 +1,131 @@
+package cache
+
+import (
+	"errors"
+	"maps"
+	"sync"
+	"testing"
+	"time"
+)
+
+// batchRecorder collects the batches handed to a WriteBehindCache flush
+type batchRecorder struct {
+	mu      sync.Mutex
+	batches []map[string]*DiskStatus
+	err     error
+}
+
+func (r *batchRecorder) flush(batch map[string]*DiskStatus) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.batches = append(r.batches, maps.Clone(batch))
+	return r.err
+}
+
+func (r *batchRecorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.batches)
+}
+
+func TestWriteBehindCache(t *testing.T) {
+	t.Run("FlushSendsDirtySet", func(t *testing.T) {
+		rec := &batchRecorder{}
+		c := NewWriteBehindCache(NewMutexCache(), rec.flush, time.Hour)
+		defer c.Close()
+
+		latest := &DiskStatus{ID: "disk-1", Temp: 50}
+		c.Update("disk-1", &DiskStatus{ID: "disk-1", Temp: 40})
+		c.Update("disk-2", &DiskStatus{ID: "disk-2"})
+		c.Update("disk-1", latest)
+		if got := c.Get("disk-1"); got != latest {
+			t.Errorf("expected the write to be readable before flushing, got %v", got)
+		}
+
+		if err := c.Flush(); err != nil {
+			t.Fatalf("flush: %v", err)
+		}
+		if len(rec.batches) != 1 {
+			t.Fatalf("expected 1 batch, got %d", len(rec.batches))
+		}
+		batch := rec.batches[0]
+		if len(batch) != 2 || batch["disk-1"] != latest || batch["disk-2"] == nil {
+			t.Errorf("expected latest disk-1 and disk-2, got %v", batch)
+		}
+
+		// Nothing is dirty any more, so flush isn't called again
+		c.Flush()
+		if len(rec.batches) != 1 {
+			t.Errorf("expected no empty batch, got %d batches", len(rec.batches))
+		}
+	})
+
+	t.Run("FailedBatchRequeued", func(t *testing.T) {
+		rec := &batchRecorder{err: errors.New("store down")}
+		c := NewWriteBehindCache(NewMutexCache(), rec.flush, time.Hour)
+		defer c.Close()
+
+		c.Update("disk-1", &DiskStatus{ID: "disk-1"})
+		if err := c.Flush(); err == nil {
+			t.Fatalf("expected the flush error")
+		}
+		rec.err = nil
+		if err := c.Flush(); err != nil {
+			t.Fatalf("flush: %v", err)
+		}
+		if len(rec.batches) != 2 || rec.batches[1]["disk-1"] == nil {
+			t.Errorf("expected disk-1 retried in the second batch, got %v", rec.batches)
+		}
+	})
+
+	t.Run("RacingWritesAgree", func(t *testing.T) {
+		rec := &batchRecorder{}
+		c := NewWriteBehindCache(NewMutexCache(), rec.flush, time.Hour)
+		defer c.Close()
+
+		var wg sync.WaitGroup
+		for g := 0; g < 8; g++ {
+			wg.Add(1)
+			go func(g int) {
+				defer wg.Done()
+				for i := 0; i < 100; i++ {
+					if i%10 == g {
+						c.Delete("disk-1")
+						continue
+					}
+					c.Update("disk-1", &DiskStatus{ID: "disk-1", Temp: g*100 + i})
+				}
+			}(g)
+		}
+		wg.Wait()
+		c.Flush()
+		if got, flushed := c.Get("disk-1"), rec.batches[0]["disk-1"]; got != flushed {
+			t.Errorf("expected the flushed value to match the cache, got %v and %v", flushed, got)
+		}
+	})
+
+	t.Run("BackgroundAndClose", func(t *testing.T) {
+		rec := &batchRecorder{}
+		c := NewWriteBehindCache(NewMutexCache(), rec.flush, time.Millisecond)
+		c.Update("disk-1", &DiskStatus{ID: "disk-1"})
+
+		deadline := time.Now().Add(time.Second)
+		for rec.count() == 0 {
+			if time.Now().After(deadline) {
+				t.Fatalf("expected a background flush")
+			}
+			time.Sleep(time.Millisecond)
+		}
+
+		c.Update("disk-2", &DiskStatus{ID: "disk-2"})
+		if err := c.Close(); err != nil {
+			t.Fatalf("close: %v", err)
+		}
+		c.Close()
+		rec.mu.Lock()
+		defer rec.mu.Unlock()
+		if last := rec.batches[len(rec.batches)-1]; last["disk-2"] == nil {
+			t.Errorf("expected Close to flush disk-2, got %v", last)
+		}
+	})
+}